@@ -0,0 +1,91 @@
+// Package cassette records HTTP traffic exchanged with router to a file and replays it back.
+//
+// Sensitive values - passwords, login nonces and proofs, cookies, verification tokens and
+// device identifiers - are redacted before the traffic is stored, so cassettes can be attached
+// to bug reports.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const version = 1
+
+// Redacted replaces sensitive values in the recorded traffic
+const Redacted = "REDACTED"
+
+// Request is the recorded HTTP request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is the recorded HTTP response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a single request with its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the recorded traffic
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads cassette from a file
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cassette %s: %w", path, err)
+	}
+	if c.Version != version {
+		return nil, fmt.Errorf("unsupported cassette version %d", c.Version)
+	}
+
+	return c, nil
+}
+
+// Save writes cassette to a file
+func (c *Cassette) Save(path string) error {
+	c.Version = version
+
+	// keep recorded XML readable, instead of escaping it for HTML
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(c)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(path, data.Bytes(), 0600)
+}
@@ -0,0 +1,185 @@
+package cassette
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mkorz/b618reboot-go/routerclient"
+	"github.com/mkorz/b618reboot-go/routeremu"
+	"github.com/stretchr/testify/assert"
+)
+
+const signalResponse = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<rsrq>-14dB</rsrq>\n<rsrp>-86dBm</rsrp>\n<rssi>-61dBm</rssi>\n<sinr>10dB</sinr>\n</response>\n"
+
+func routerHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		http.SetCookie(w, &http.Cookie{Name: "SessionID", Value: "secret-session", Path: "/", HttpOnly: true})
+	case "/api/user/challenge_login":
+		w.Header().Set("__RequestVerificationToken", "secret-token")
+		fmt.Fprint(w, "<response><salt>secret-salt</salt><servernonce>secret-nonce</servernonce><iterations>100</iterations></response>")
+	case "/api/device/information":
+		fmt.Fprint(w, "<response><DeviceName>B618s-22d</DeviceName><Imei>863351039999999</Imei><SerialNumber>ABC</SerialNumber><Msisdn></Msisdn></response>")
+	case "/api/device/signal":
+		fmt.Fprint(w, signalResponse)
+	}
+}
+
+func record(t *testing.T, path string, requests func(client *http.Client, url string)) {
+	ts := httptest.NewServer(http.HandlerFunc(routerHandler))
+	defer ts.Close()
+
+	requests(&http.Client{Transport: NewRecorder(path, nil)}, ts.URL)
+}
+
+func TestRecorderRedactsSensitiveValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.json")
+
+	record(t, path, func(client *http.Client, url string) {
+		resp, err := client.Get(url + "/")
+		assert.Nil(t, err, "error sending request: %q", err)
+		resp.Body.Close()
+
+		req, _ := http.NewRequest("POST", url+"/api/user/challenge_login", strings.NewReader("<request><username>admin</username><firstnonce>secret-first</firstnonce><mode>1</mode></request>"))
+		req.Header.Set("__RequestVerificationToken", "secret-request-token")
+		req.Header.Set("Cookie", "SessionID=secret-session")
+		resp, err = client.Do(req)
+		assert.Nil(t, err, "error sending request: %q", err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Contains(t, string(body), "secret-salt", "response passed to client should not be redacted")
+
+		resp, err = client.Get(url + "/api/device/information")
+		assert.Nil(t, err, "error sending request: %q", err)
+		resp.Body.Close()
+	})
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err, "error reading cassette: %q", err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "admin")
+	assert.NotContains(t, string(data), "863351039999999")
+	assert.Contains(t, string(data), "<Imei>REDACTED</Imei>", "recorded XML should not be escaped")
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err, "error reading cassette: %q", err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	c, err := Load(path)
+	assert.Nil(t, err, "error loading cassette: %q", err)
+	assert.Len(t, c.Interactions, 3)
+
+	assert.Equal(t, []string{"SessionID=REDACTED; Path=/; HttpOnly"}, c.Interactions[0].Response.Header.Values("Set-Cookie"))
+
+	login := c.Interactions[1]
+	assert.Equal(t, "POST", login.Request.Method)
+	assert.Equal(t, "/api/user/challenge_login", login.Request.URL)
+	assert.Equal(t, "<request><username>REDACTED</username><firstnonce>REDACTED</firstnonce><mode>1</mode></request>", login.Request.Body)
+	assert.Equal(t, "REDACTED", login.Request.Header.Get("__RequestVerificationToken"))
+	assert.Equal(t, "REDACTED", login.Request.Header.Get("Cookie"))
+	assert.Equal(t, "REDACTED", login.Response.Header.Get("__RequestVerificationToken"))
+	assert.Equal(t, "<response><salt>"+strings.Repeat("0", 64)+"</salt><servernonce>REDACTED</servernonce><iterations>100</iterations></response>", login.Response.Body)
+
+	assert.Equal(t, "<response><DeviceName>B618s-22d</DeviceName><Imei>REDACTED</Imei><SerialNumber>REDACTED</SerialNumber><Msisdn></Msisdn></response>", c.Interactions[2].Response.Body)
+}
+
+func TestEncryptedBodyIsRedacted(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8;enc")
+
+	assert.Equal(t, Redacted, redactBody("0a1b2c", header))
+}
+
+func TestReplayedTrafficIsServedToRouterClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.json")
+
+	ts := httptest.NewServer(http.HandlerFunc(routerHandler))
+	client, err := routerclient.NewRouterClient(ts.URL, "user", "pass", routerclient.WithTransport(NewRecorder(path, nil)))
+	assert.Nil(t, err, "error creating client: %q", err)
+	recorded, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+	ts.Close()
+
+	c, err := Load(path)
+	assert.Nil(t, err, "error loading cassette: %q", err)
+	replayer := NewReplayer(c)
+
+	client, err = routerclient.NewRouterClient(ts.URL, "user", "pass", routerclient.WithTransport(replayer))
+	assert.Nil(t, err, "error creating client: %q", err)
+	replayed, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+
+	assert.Equal(t, recorded, replayed)
+	assert.Equal(t, -86.0, *replayed.RSRP)
+	assert.Equal(t, 0, replayer.Remaining())
+
+	_, err = client.GetSignalStats()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cassette: no recorded response for GET /api/device/signal")
+}
+
+func TestReplayedLoginSucceeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.json")
+
+	e, err := routeremu.New(routeremu.Config{Password: "secret", RSAKeyBits: 1024})
+	assert.Nil(t, err, "error creating emulator: %q", err)
+	ts := httptest.NewServer(e)
+	client, err := routerclient.NewRouterClient(ts.URL, "admin", "secret", routerclient.WithTransport(NewRecorder(path, nil)))
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	recorded, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+	ts.Close()
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err, "error reading cassette: %q", err)
+	assert.NotContains(t, string(data), "<rsan>REDACTED", "login values parsed by client should have placeholders")
+
+	c, err := Load(path)
+	assert.Nil(t, err, "error loading cassette: %q", err)
+	replayer := NewReplayer(c)
+
+	// password is not recorded, replay works with any
+	client, err = routerclient.NewRouterClient(ts.URL, "user", "pass", routerclient.WithTransport(replayer))
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	replayed, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+
+	assert.Equal(t, recorded, replayed)
+	assert.Equal(t, 0, replayer.Remaining())
+}
+
+func TestReplayerKeepsRecordedOrder(t *testing.T) {
+	c := &Cassette{Interactions: []Interaction{
+		{Request: Request{Method: "GET", URL: "/api/device/signal"}, Response: Response{StatusCode: 200, Body: "first"}},
+		{Request: Request{Method: "GET", URL: "/"}, Response: Response{StatusCode: 200, Body: "root"}},
+		{Request: Request{Method: "GET", URL: "/api/device/signal"}, Response: Response{StatusCode: 500, Body: "second"}},
+	}}
+	client := &http.Client{Transport: NewReplayer(c)}
+
+	for _, expected := range []string{"first", "second"} {
+		resp, err := client.Get("http://router/api/device/signal")
+		assert.Nil(t, err, "error sending request: %q", err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, expected, string(body))
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.json")
+	err := ioutil.WriteFile(path, []byte(`{"version": 99}`), 0600)
+	assert.Nil(t, err, "error writing cassette: %q", err)
+
+	_, err = Load(path)
+	assert.EqualError(t, err, "unsupported cassette version 99")
+}
@@ -0,0 +1,89 @@
+package cassette
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Recorder is a http.RoundTripper recording the traffic to a cassette file.
+// The file is written after every exchange, so it is complete even if the program fails.
+type Recorder struct {
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates recorder writing traffic sent through next to cassette at path.
+// When next is nil, http.DefaultTransport is used.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{path: path, next: next, cassette: Cassette{Version: version}}
+}
+
+// RoundTrip sends the request and records the exchange
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Header: redactHeader(req.Header),
+			Body:   redactBody(string(reqBody), req.Header),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header),
+			Body:       redactBody(string(respBody), resp.Header),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	err = r.cassette.Save(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Cassette returns copy of the traffic recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.cassette
+	c.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	return &c
+}
@@ -0,0 +1,105 @@
+package cassette
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// sensitiveHeaders are replaced as a whole
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"__RequestVerificationToken",
+	"__RequestVerificationTokenone",
+	"__RequestVerificationTokentwo",
+}
+
+// sensitiveElements are XML elements with values redacted, compared case-insensitively
+var sensitiveElements = map[string]bool{
+	// login
+	"username":           true,
+	"password":           true,
+	"firstnonce":         true,
+	"finalnonce":         true,
+	"clientproof":        true,
+	"servernonce":        true,
+	"salt":               true,
+	"serversignature":    true,
+	"rsan":               true,
+	"rsapubkeysignature": true,
+	// session
+	"token":   true,
+	"sesinfo": true,
+	"tokinfo": true,
+	// device identifiers
+	"imei":             true,
+	"imeisvn":          true,
+	"imsi":             true,
+	"iccid":            true,
+	"msisdn":           true,
+	"serialnumber":     true,
+	"macaddress1":      true,
+	"macaddress2":      true,
+	"wifimacaddrwl0":   true,
+	"wifimacaddrwl1":   true,
+	"wanipaddress":     true,
+	"wanipv6address":   true,
+	"primarydns":       true,
+	"secondarydns":     true,
+	"primaryipv6dns":   true,
+	"secondaryipv6dns": true,
+}
+
+// placeholders replace sensitive values the client parses instead of Redacted, so that recorded login
+// can be replayed. They are well-formed, but unrelated to any password.
+var placeholders = map[string]string{
+	"salt": strings.Repeat("0", 64),
+	// 2048 bit modulus, so that requests can still be encrypted during replay
+	"rsan": strings.Repeat("f", 512),
+}
+
+var elementRegexp = regexp.MustCompile(`<([A-Za-z0-9_]+)>([^<]*)</([A-Za-z0-9_]+)>`)
+
+// redactBody replaces values of sensitive XML elements. Encrypted bodies are replaced as a whole
+func redactBody(body string, header http.Header) string {
+	if strings.HasSuffix(header.Get("Content-Type"), ";enc") {
+		return Redacted
+	}
+
+	return elementRegexp.ReplaceAllStringFunc(body, func(element string) string {
+		m := elementRegexp.FindStringSubmatch(element)
+		name := strings.ToLower(m[1])
+		if m[1] != m[3] || m[2] == "" || !sensitiveElements[name] {
+			return element
+		}
+
+		value := Redacted
+		if placeholder, ok := placeholders[name]; ok {
+			value = placeholder
+		}
+		return "<" + m[1] + ">" + value + "</" + m[1] + ">"
+	})
+}
+
+// redactHeader returns copy of the header with sensitive values replaced
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := redacted[http.CanonicalHeaderKey(name)]; ok {
+			redacted.Set(name, Redacted)
+		}
+	}
+
+	// keep cookie names and attributes, so that replayed responses still set them
+	for i, cookie := range redacted.Values("Set-Cookie") {
+		name := strings.SplitN(cookie, "=", 2)[0]
+		attrs := ""
+		if j := strings.Index(cookie, ";"); j >= 0 {
+			attrs = cookie[j:]
+		}
+		redacted["Set-Cookie"][i] = name + "=" + Redacted + attrs
+	}
+
+	return redacted
+}
@@ -0,0 +1,69 @@
+package cassette
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Replayer is a http.RoundTripper serving responses from a cassette. Each request gets
+// the first not yet used interaction with the same method and URL, so repeated requests
+// are answered in the recorded order.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer creates replayer serving responses from the cassette
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{cassette: c, used: make([]bool, len(c.Interactions))}
+}
+
+// RoundTrip returns the recorded response for the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.RequestURI() {
+			continue
+		}
+		r.used[i] = true
+
+		resp := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+			StatusCode:    resp.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        resp.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(resp.Body)),
+			ContentLength: int64(len(resp.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette: no recorded response for %s %s", req.Method, req.URL.RequestURI())
+}
+
+// Remaining returns number of interactions not replayed yet
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
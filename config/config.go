@@ -0,0 +1,175 @@
+// Package config reads named router profiles from a YAML config file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mkorz/b618reboot-go/routerclient"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrProfileNotFound is returned when requested router profile is not defined in config
+var ErrProfileNotFound = errors.New("router profile not found")
+
+// Profile holds settings of a single router
+type Profile struct {
+	URL          string `yaml:"url"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+	PasswordCmd  string `yaml:"password_cmd"`
+	PasswordMode string `yaml:"password_mode"`
+	SessionCache string `yaml:"session_cache"`
+}
+
+// Config is the content of the config file
+type Config struct {
+	// Default is the name of profile used when no router is selected
+	Default string              `yaml:"default"`
+	Routers map[string]*Profile `yaml:"routers"`
+	// Quality holds thresholds of signal quality grades, the ones not set are defaults
+	Quality routerclient.QualityThresholds `yaml:"quality"`
+}
+
+// DefaultPath returns location of the config file in user's config directory
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "b618reboot", "config.yaml")
+}
+
+// Load reads config from path. Missing file results in an empty config
+func Load(path string) (*Config, error) {
+	quality := routerclient.DefaultQualityThresholds
+	quality.Bars = append([]float64(nil), quality.Bars...)
+
+	cfg := &Config{Quality: quality}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Names returns sorted names of the router profiles
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Routers))
+	for name := range c.Routers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Profile returns profile with given name. Empty name selects the default profile,
+// if there is none, empty profile is returned
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return &Profile{}, nil
+	}
+
+	profile, ok := c.Routers[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+
+	return profile, nil
+}
+
+// Validate checks the default profile and all router profiles, returning all problems found
+func (c *Config) Validate() error {
+	var problems []error
+
+	if c.Default != "" {
+		if _, ok := c.Routers[c.Default]; !ok {
+			problems = append(problems, fmt.Errorf("default: %w: %q", ErrProfileNotFound, c.Default))
+		}
+	}
+
+	err := c.Quality.Validate()
+	if err != nil {
+		problems = append(problems, fmt.Errorf("quality: %w", err))
+	}
+
+	for _, name := range c.Names() {
+		profile := c.Routers[name]
+		if profile == nil {
+			problems = append(problems, fmt.Errorf("router %q: profile is empty", name))
+			continue
+		}
+
+		err := profile.Validate()
+		if err != nil {
+			problems = append(problems, fmt.Errorf("router %q: %w", name, err))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: problems}
+}
+
+// Validate checks values set in the profile. Values that are not set are not checked,
+// as they can be provided with flags or environment variables
+func (p *Profile) Validate() error {
+	if p.URL != "" {
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid url %q: expected http(s)://host", p.URL)
+		}
+	}
+
+	if p.PasswordMode != "" {
+		_, err := routerclient.ParsePasswordMode(p.PasswordMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidationError lists all problems found in the config
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("invalid config, %d problem(s) found", len(e.Problems))
+	for _, p := range e.Problems {
+		msg += "\n  " + p.Error()
+	}
+
+	return msg
+}
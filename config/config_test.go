@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkorz/b618reboot-go/routerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+default: home
+routers:
+  home:
+    url: http://192.168.1.1
+    username: admin
+    password: secret
+  office:
+    url: https://10.0.0.1
+    username: user
+    password_mode: sha256
+    session_cache: /tmp/office.json
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := ioutil.WriteFile(path, []byte(content), 0600)
+	assert.Nil(t, err, "error writing config: %q", err)
+	return path
+}
+
+func TestCanLoadConfig(t *testing.T) {
+	cfg, err := Load(writeConfig(t, testConfig))
+
+	assert.Nil(t, err, "error loading config: %q", err)
+	assert.Equal(t, "home", cfg.Default)
+	assert.Equal(t, []string{"home", "office"}, cfg.Names())
+	assert.Equal(t, &Profile{
+		URL:          "https://10.0.0.1",
+		Username:     "user",
+		PasswordMode: "sha256",
+		SessionCache: "/tmp/office.json",
+	}, cfg.Routers["office"])
+	assert.Nil(t, cfg.Validate())
+}
+
+func TestMissingConfigIsEmpty(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+
+	assert.Nil(t, err, "error loading config: %q", err)
+	assert.Empty(t, cfg.Routers)
+
+	profile, err := cfg.Profile("")
+	assert.Nil(t, err, "error getting profile: %q", err)
+	assert.Equal(t, &Profile{}, profile)
+}
+
+func TestInvalidYAMLIsReported(t *testing.T) {
+	_, err := Load(writeConfig(t, "routers: [\n"))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "error parsing config")
+}
+
+func TestProfileSelection(t *testing.T) {
+	cfg, err := Load(writeConfig(t, testConfig))
+	assert.Nil(t, err, "error loading config: %q", err)
+
+	profile, err := cfg.Profile("")
+	assert.Nil(t, err, "error getting profile: %q", err)
+	assert.Equal(t, "http://192.168.1.1", profile.URL)
+
+	profile, err = cfg.Profile("office")
+	assert.Nil(t, err, "error getting profile: %q", err)
+	assert.Equal(t, "https://10.0.0.1", profile.URL)
+
+	_, err = cfg.Profile("garage")
+	assert.True(t, errors.Is(err, ErrProfileNotFound))
+	assert.EqualError(t, err, `router profile not found: "garage"`)
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+default: garage
+routers:
+  home:
+    url: 192.168.1.1
+  office:
+    url: http://10.0.0.1
+    password_mode: md5
+  empty:
+`))
+	assert.Nil(t, err, "error loading config: %q", err)
+
+	err = cfg.Validate()
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Problems, 4)
+	assert.EqualError(t, err, `invalid config, 4 problem(s) found
+  default: router profile not found: "garage"
+  router "empty": profile is empty
+  router "home": invalid url "192.168.1.1": expected http(s)://host
+  router "office": unknown password mode "md5"`)
+}
+
+func TestQualityThresholdsOverrideDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+quality:
+  rsrp:
+    excellent: -75
+  bars: [-115, -105, -95]
+`))
+	assert.Nil(t, err, "error loading config: %q", err)
+
+	expected := routerclient.DefaultQualityThresholds
+	expected.RSRP.Excellent = -75
+	expected.Bars = []float64{-115, -105, -95}
+	assert.Equal(t, expected, cfg.Quality)
+	assert.Nil(t, cfg.Validate())
+	assert.Equal(t, []float64{-120, -110, -100, -90, -80}, routerclient.DefaultQualityThresholds.Bars)
+
+	cfg, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Nil(t, err, "error loading config: %q", err)
+	assert.Equal(t, routerclient.DefaultQualityThresholds, cfg.Quality)
+}
+
+func TestInvalidQualityThresholdsAreReported(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+quality:
+  sinr:
+    good: 25
+`))
+	assert.Nil(t, err, "error loading config: %q", err)
+
+	assert.EqualError(t, cfg.Validate(), `invalid config, 1 problem(s) found
+  quality: sinr: thresholds must be in order excellent >= good >= fair >= poor, got 20, 25, 0, -20`)
+}
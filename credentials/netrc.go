@@ -0,0 +1,142 @@
+package credentials
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// netrcEntry is a single machine (or default) entry of the netrc file
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// DefaultNetrcPath returns location of the netrc file, taken from NETRC variable or user's home
+func DefaultNetrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// Netrc returns provider looking up the router host in netrc file. Entry with login not matching
+// requested username is skipped. If username is not known, the login from the entry is used.
+func Netrc(path string) Provider {
+	return ProviderFunc(func(req Request) (Credentials, error) {
+		entries, err := readNetrc(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return Credentials{}, ErrNotFound
+		}
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		for _, e := range entries {
+			if e.machine != "" && !strings.EqualFold(e.machine, req.Host) {
+				continue
+			}
+			if req.Username != "" && e.login != "" && e.login != req.Username {
+				continue
+			}
+			if e.password == "" {
+				continue
+			}
+
+			username := req.Username
+			if username == "" {
+				username = e.login
+			}
+			return Credentials{Username: username, Password: e.password}, nil
+		}
+
+		return Credentials{}, ErrNotFound
+	})
+}
+
+// readNetrc parses netrc file. The default entry, if present, is always the last one
+func readNetrc(path string) ([]netrcEntry, error) {
+	if path == "" {
+		return nil, os.ErrNotExist
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []netrcEntry
+	var entry *netrcEntry
+	inMacro := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if inMacro {
+			// macro definition ends with an empty line
+			inMacro = len(fields) != 0
+			continue
+		}
+
+		for i := 0; i < len(fields); i++ {
+			value := func() string {
+				if i+1 < len(fields) {
+					i++
+					return fields[i]
+				}
+				return ""
+			}
+
+			switch fields[i] {
+			case "machine":
+				entries = append(entries, netrcEntry{machine: value()})
+				entry = &entries[len(entries)-1]
+			case "default":
+				entries = append(entries, netrcEntry{})
+				entry = &entries[len(entries)-1]
+			case "login":
+				if entry != nil {
+					entry.login = value()
+				}
+			case "password":
+				if entry != nil {
+					entry.password = value()
+				}
+			case "account":
+				value()
+			case "macdef":
+				value()
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading netrc: %w", err)
+	}
+
+	// default entry matches only when no machine does
+	for i, e := range entries {
+		if e.machine == "" {
+			entries = append(append(entries[:i:i], entries[i+1:]...), e)
+			break
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,64 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testNetrc = `
+default login admin password defaultpass
+
+machine example.com login user password examplepass
+
+macdef init
+machine 192.168.1.1 login admin password macropass
+
+machine 192.168.1.1
+  login other
+  password otherpass
+machine 192.168.1.1
+  login admin
+  account ignored
+  password routerpass
+`
+
+func writeNetrc(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), ".netrc")
+	err := ioutil.WriteFile(path, []byte(testNetrc), 0600)
+	assert.Nil(t, err, "error writing netrc: %q", err)
+	return path
+}
+
+func TestNetrcLooksUpHostAndLogin(t *testing.T) {
+	creds, err := Netrc(writeNetrc(t)).Lookup(Request{Host: "192.168.1.1", Username: "admin"})
+
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, Credentials{Username: "admin", Password: "routerpass"}, creds)
+}
+
+func TestNetrcProvidesLoginWhenUsernameUnknown(t *testing.T) {
+	creds, err := Netrc(writeNetrc(t)).Lookup(Request{Host: "EXAMPLE.com"})
+
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, Credentials{Username: "user", Password: "examplepass"}, creds)
+}
+
+func TestNetrcFallsBackToDefault(t *testing.T) {
+	creds, err := Netrc(writeNetrc(t)).Lookup(Request{Host: "10.0.0.1", Username: "admin"})
+
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, "defaultpass", creds.Password)
+
+	_, err = Netrc(writeNetrc(t)).Lookup(Request{Host: "10.0.0.1", Username: "nobody"})
+
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMissingNetrcHasNoCredentials(t *testing.T) {
+	_, err := Netrc(filepath.Join(t.TempDir(), ".netrc")).Lookup(Request{Host: "192.168.1.1"})
+
+	assert.Equal(t, ErrNotFound, err)
+}
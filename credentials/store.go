@@ -0,0 +1,270 @@
+package credentials
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	storeVersion = 1
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	saltSize  = 16
+	keySize   = 32
+	nonceSize = 24
+)
+
+// ErrWrongPassphrase is returned when the store cannot be decrypted with given passphrase
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted credential store")
+
+// storeFile is the on-disk format of the store, entries are encrypted as a whole
+type storeFile struct {
+	Version int    `json:"version"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Box     []byte `json:"box"`
+}
+
+// StoreEntry identifies the credentials kept in the store
+type StoreEntry struct {
+	Host     string
+	Username string
+}
+
+// Store keeps router passwords in a file encrypted with key derived from passphrase
+// with scrypt and NaCl secretbox
+type Store struct {
+	path       string
+	passphrase []byte
+	passwords  map[string]string
+}
+
+// DefaultStorePath returns location of the credential store in user's config directory
+func DefaultStorePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "b618reboot", "credentials.json")
+}
+
+// OpenStore reads and decrypts the store. Missing file results in an empty store
+func OpenStore(path string, passphrase []byte) (*Store, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+
+	s := &Store{path: path, passphrase: passphrase, passwords: map[string]string{}}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f storeFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing credential store: %w", err)
+	}
+	if f.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported credential store version %d", f.Version)
+	}
+	// parameters are fixed, so a tampered file cannot make key derivation weak or exhaust memory
+	if f.N != scryptN || f.R != scryptR || f.P != scryptP {
+		return nil, fmt.Errorf("unsupported credential store key parameters n=%d r=%d p=%d", f.N, f.R, f.P)
+	}
+	if len(f.Nonce) != nonceSize {
+		return nil, ErrWrongPassphrase
+	}
+
+	key, err := scrypt.Key(passphrase, f.Salt, f.N, f.R, f.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %w", err)
+	}
+
+	var nonce [nonceSize]byte
+	var secretKey [keySize]byte
+	copy(nonce[:], f.Nonce)
+	copy(secretKey[:], key)
+
+	plain, ok := secretbox.Open(nil, f.Box, &nonce, &secretKey)
+	if !ok {
+		return nil, ErrWrongPassphrase
+	}
+
+	err = json.Unmarshal(plain, &s.passwords)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing credential store: %w", err)
+	}
+
+	return s, nil
+}
+
+func storeKey(host, username string) string {
+	return username + "@" + strings.ToLower(host)
+}
+
+// Set stores password for the account on router host
+func (s *Store) Set(host, username, password string) {
+	s.passwords[storeKey(host, username)] = password
+}
+
+// Remove deletes password for the account on router host, returns false if there was none
+func (s *Store) Remove(host, username string) bool {
+	key := storeKey(host, username)
+	_, ok := s.passwords[key]
+	delete(s.passwords, key)
+	return ok
+}
+
+// Entries lists the accounts in the store sorted by host and username
+func (s *Store) Entries() []StoreEntry {
+	entries := make([]StoreEntry, 0, len(s.passwords))
+	for key := range s.passwords {
+		i := strings.LastIndex(key, "@")
+		entries = append(entries, StoreEntry{Host: key[i+1:], Username: key[:i]})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Host != entries[j].Host {
+			return entries[i].Host < entries[j].Host
+		}
+		return entries[i].Username < entries[j].Username
+	})
+
+	return entries
+}
+
+// Lookup returns password for the requested router. If username is not known,
+// the only account stored for the host is used.
+func (s *Store) Lookup(req Request) (Credentials, error) {
+	if req.Username != "" {
+		password, ok := s.passwords[storeKey(req.Host, req.Username)]
+		if !ok {
+			return Credentials{}, ErrNotFound
+		}
+		return Credentials{Username: req.Username, Password: password}, nil
+	}
+
+	var found []StoreEntry
+	for _, e := range s.Entries() {
+		if strings.EqualFold(e.Host, req.Host) {
+			found = append(found, e)
+		}
+	}
+	if len(found) != 1 {
+		return Credentials{}, ErrNotFound
+	}
+
+	return Credentials{Username: found[0].Username, Password: s.passwords[storeKey(found[0].Host, found[0].Username)]}, nil
+}
+
+// Save encrypts the store with new salt and nonce and writes it readable only by the owner
+func (s *Store) Save() error {
+	plain, err := json.Marshal(s.passwords)
+	if err != nil {
+		return err
+	}
+
+	f := storeFile{Version: storeVersion, N: scryptN, R: scryptR, P: scryptP, Salt: make([]byte, saltSize)}
+	_, err = io.ReadFull(rand.Reader, f.Salt)
+	if err != nil {
+		return err
+	}
+
+	key, err := scrypt.Key(s.passphrase, f.Salt, f.N, f.R, f.P, keySize)
+	if err != nil {
+		return fmt.Errorf("error deriving key: %w", err)
+	}
+
+	var nonce [nonceSize]byte
+	var secretKey [keySize]byte
+	_, err = io.ReadFull(rand.Reader, nonce[:])
+	if err != nil {
+		return err
+	}
+	copy(secretKey[:], key)
+
+	f.Nonce = nonce[:]
+	f.Box = secretbox.Seal(nil, plain, &nonce, &secretKey)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// StoreProvider returns provider looking up passwords in the store at path. The passphrase
+// is asked for only when the store exists, missing store has no credentials. Store which
+// can't be unlocked because there is no passphrase has no credentials either, so Chain
+// goes on to the next provider.
+func StoreProvider(path string, passphrase func() ([]byte, error)) Provider {
+	return ProviderFunc(func(req Request) (Credentials, error) {
+		if path == "" {
+			return Credentials{}, ErrNotFound
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return Credentials{}, ErrNotFound
+		}
+
+		p, err := passphrase()
+		if err != nil {
+			return Credentials{}, fmt.Errorf("%v: %w", err, ErrNotFound)
+		}
+
+		s, err := OpenStore(path, p)
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		return s.Lookup(req)
+	})
+}
@@ -0,0 +1,141 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "b618reboot", "credentials.json")
+
+	store, err := OpenStore(path, []byte("passphrase"))
+	assert.Nil(t, err, "error opening store: %q", err)
+	store.Set("192.168.1.1", "admin", "secret")
+	store.Set("10.0.0.1", "user", "other")
+	err = store.Save()
+	assert.Nil(t, err, "error saving store: %q", err)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err, "store should be written: %q", err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err, "error reading store: %q", err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "192.168.1.1")
+
+	store, err = OpenStore(path, []byte("passphrase"))
+	assert.Nil(t, err, "error opening store: %q", err)
+	assert.Equal(t, []StoreEntry{{Host: "10.0.0.1", Username: "user"}, {Host: "192.168.1.1", Username: "admin"}}, store.Entries())
+
+	creds, err := store.Lookup(Request{Host: "192.168.1.1", Username: "admin"})
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, "secret", creds.Password)
+}
+
+func TestStoreRejectsWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+
+	store, err := OpenStore(path, []byte("passphrase"))
+	assert.Nil(t, err, "error opening store: %q", err)
+	store.Set("192.168.1.1", "admin", "secret")
+	err = store.Save()
+	assert.Nil(t, err, "error saving store: %q", err)
+
+	_, err = OpenStore(path, []byte("wrong"))
+	assert.Equal(t, ErrWrongPassphrase, err)
+
+	_, err = OpenStore(path, nil)
+	assert.EqualError(t, err, "passphrase cannot be empty")
+}
+
+func TestStoreRejectsOtherKeyParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+
+	store, err := OpenStore(path, []byte("passphrase"))
+	assert.Nil(t, err, "error opening store: %q", err)
+	err = store.Save()
+	assert.Nil(t, err, "error saving store: %q", err)
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err, "error reading store: %q", err)
+
+	for _, params := range [][3]int{{2, 8, 1}, {1 << 20, 8, 1}, {scryptN, 1, 1}, {scryptN, 8, 16}} {
+		var f storeFile
+		err = json.Unmarshal(data, &f)
+		assert.Nil(t, err, "error parsing store: %q", err)
+		f.N, f.R, f.P = params[0], params[1], params[2]
+		tampered, err := json.Marshal(f)
+		assert.Nil(t, err, "error encoding store: %q", err)
+		err = ioutil.WriteFile(path, tampered, 0600)
+		assert.Nil(t, err, "error writing store: %q", err)
+
+		_, err = OpenStore(path, []byte("passphrase"))
+		assert.EqualError(t, err, fmt.Sprintf("unsupported credential store key parameters n=%d r=%d p=%d", params[0], params[1], params[2]))
+	}
+}
+
+func TestStoreLookup(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "credentials.json"), []byte("passphrase"))
+	assert.Nil(t, err, "error opening store: %q", err)
+	store.Set("Router.lan", "admin", "secret")
+	store.Set("10.0.0.1", "admin", "first")
+	store.Set("10.0.0.1", "user", "second")
+
+	creds, err := store.Lookup(Request{Host: "router.LAN"})
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, Credentials{Username: "admin", Password: "secret"}, creds)
+
+	_, err = store.Lookup(Request{Host: "10.0.0.1"})
+	assert.Equal(t, ErrNotFound, err, "username is needed when host has several accounts")
+
+	_, err = store.Lookup(Request{Host: "router.lan", Username: "user"})
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.True(t, store.Remove("10.0.0.1", "user"))
+	assert.False(t, store.Remove("10.0.0.1", "user"))
+
+	creds, err = store.Lookup(Request{Host: "10.0.0.1"})
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, "first", creds.Password)
+}
+
+func TestStoreProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	asked := 0
+	passphrase := func() ([]byte, error) {
+		asked++
+		return []byte("passphrase"), nil
+	}
+
+	_, err := StoreProvider(path, passphrase).Lookup(testRequest)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, 0, asked, "passphrase should not be asked for missing store")
+
+	store, err := OpenStore(path, []byte("passphrase"))
+	assert.Nil(t, err, "error opening store: %q", err)
+	store.Set(testRequest.Host, testRequest.Username, "secret")
+	err = store.Save()
+	assert.Nil(t, err, "error saving store: %q", err)
+
+	creds, err := StoreProvider(path, passphrase).Lookup(testRequest)
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, "secret", creds.Password)
+	assert.Equal(t, 1, asked)
+
+	failing := func() ([]byte, error) { return nil, errors.New("no passphrase") }
+	_, err = StoreProvider(path, failing).Lookup(testRequest)
+	assert.EqualError(t, err, "no passphrase: credentials not found")
+	assert.True(t, errors.Is(err, ErrNotFound), "locked store should have no credentials: %q", err)
+
+	creds, err = Chain(StoreProvider(path, failing), Static("fallback")).Lookup(testRequest)
+	assert.Nil(t, err, "error looking up credentials: %q", err)
+	assert.Equal(t, "fallback", creds.Password)
+}
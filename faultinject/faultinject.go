@@ -0,0 +1,277 @@
+// Package faultinject provides http.RoundTripper injecting faults into traffic with router,
+// for testing how tools built on RouterClient cope with misbehaving routers.
+package faultinject
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type kind int
+
+const (
+	kindNone kind = iota
+	kindLatency
+	kindReset
+	kindTruncate
+	kindStatus
+	kindDropToken
+	kindAPIError
+)
+
+// Fault describes misbehaviour injected into a single request
+type Fault struct {
+	kind  kind
+	delay time.Duration
+	code  int
+}
+
+// None passes the request to router unchanged
+func None() Fault {
+	return Fault{kind: kindNone}
+}
+
+// Latency delays the request by d before passing it to router
+func Latency(d time.Duration) Fault {
+	return Fault{kind: kindLatency, delay: d}
+}
+
+// Reset fails the request with connection reset, without passing it to router
+func Reset() Fault {
+	return Fault{kind: kindReset}
+}
+
+// Truncate cuts the response body from router in half
+func Truncate() Fault {
+	return Fault{kind: kindTruncate}
+}
+
+// Status responds with HTTP status code and HTML error page, without passing the request to router
+func Status(code int) Fault {
+	return Fault{kind: kindStatus, code: code}
+}
+
+// DropToken removes verification token headers from the response from router
+func DropToken() Fault {
+	return Fault{kind: kindDropToken}
+}
+
+// APIError responds with HiLink error envelope with given code, without passing the request to router
+func APIError(code int) Fault {
+	return Fault{kind: kindAPIError, code: code}
+}
+
+func (f Fault) String() string {
+	switch f.kind {
+	case kindLatency:
+		return fmt.Sprintf("latency %s", f.delay)
+	case kindReset:
+		return "connection reset"
+	case kindTruncate:
+		return "truncated response"
+	case kindStatus:
+		return fmt.Sprintf("HTTP status %d", f.code)
+	case kindDropToken:
+		return "dropped token"
+	case kindAPIError:
+		return fmt.Sprintf("API error %d", f.code)
+	default:
+		return "none"
+	}
+}
+
+// Rule injects fault with given probability into requests matching method and path
+type Rule struct {
+	// Method and Path of matching requests, empty matches all
+	Method string
+	Path   string
+	// Probability of injecting the fault, from 0 to 1
+	Probability float64
+	Fault       Fault
+}
+
+func (r Rule) matches(req *http.Request) bool {
+	return (r.Method == "" || r.Method == req.Method) && (r.Path == "" || r.Path == req.URL.Path)
+}
+
+// Injection is a fault injected into a request
+type Injection struct {
+	Method string
+	Path   string
+	Fault  Fault
+}
+
+// Transport is a http.RoundTripper injecting faults into requests. Scripted faults are applied
+// to the following requests in order, requests not covered by the script are subject to the rules.
+type Transport struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	script   []scripted
+	rules    []Rule
+	injected []Injection
+}
+
+type scripted struct {
+	path  string
+	fault Fault
+}
+
+// NewTransport creates transport passing requests to next, or http.DefaultTransport when nil
+func NewTransport(next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{next: next, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Seed makes the probabilistic rules deterministic
+func (t *Transport) Seed(seed int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rand = rand.New(rand.NewSource(seed))
+}
+
+// Script queues faults for the following requests, one fault per request
+func (t *Transport) Script(faults ...Fault) {
+	t.ScriptPath("", faults...)
+}
+
+// ScriptPath queues faults for the following requests to path, one fault per request
+func (t *Transport) ScriptPath(path string, faults ...Fault) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, f := range faults {
+		t.script = append(t.script, scripted{path: path, fault: f})
+	}
+}
+
+// AddRule adds probabilistic rule, rules are checked in the order they were added
+func (t *Transport) AddRule(r Rule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rules = append(t.rules, r)
+}
+
+// Injected returns faults injected so far
+func (t *Transport) Injected() []Injection {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Injection(nil), t.injected...)
+}
+
+// fault selects fault for the request
+func (t *Transport) fault(req *http.Request) Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fault := None()
+	found := false
+	for i, s := range t.script {
+		if s.path == "" || s.path == req.URL.Path {
+			fault, found = s.fault, true
+			t.script = append(t.script[:i], t.script[i+1:]...)
+			break
+		}
+	}
+
+	if !found {
+		for _, r := range t.rules {
+			if r.matches(req) && t.rand.Float64() < r.Probability {
+				fault = r.Fault
+				break
+			}
+		}
+	}
+
+	if fault.kind != kindNone {
+		t.injected = append(t.injected, Injection{Method: req.Method, Path: req.URL.Path, Fault: fault})
+	}
+
+	return fault
+}
+
+// RoundTrip passes the request to router, injecting the selected fault
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault := t.fault(req)
+
+	switch fault.kind {
+	case kindLatency:
+		timer := time.NewTimer(fault.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			closeBody(req)
+			return nil, req.Context().Err()
+		}
+
+	case kindReset:
+		closeBody(req)
+		return nil, fmt.Errorf("faultinject: %w", syscall.ECONNRESET)
+
+	case kindStatus:
+		closeBody(req)
+		return response(req, fault.code, fmt.Sprintf("<html><body><h1>%d %s</h1></body></html>", fault.code, http.StatusText(fault.code))), nil
+
+	case kindAPIError:
+		closeBody(req)
+		return response(req, http.StatusOK, fmt.Sprintf("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>%d</code>\n<message></message>\n</error>\n", fault.code)), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fault.kind {
+	case kindTruncate:
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = body[:len(body)/2]
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Del("Content-Length")
+
+	case kindDropToken:
+		for name := range resp.Header {
+			if strings.HasPrefix(strings.ToLower(name), "__requestverificationtoken") {
+				resp.Header.Del(name)
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+func response(req *http.Request, code int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/html"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,175 @@
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mkorz/b618reboot-go/routerclient"
+	"github.com/mkorz/b618reboot-go/routeremu"
+	"github.com/stretchr/testify/assert"
+)
+
+const signalPath = "/api/device/signal"
+
+func newRouter(t *testing.T) (*routeremu.Emulator, *Transport, *routerclient.RouterClient) {
+	emulator, err := routeremu.New(routeremu.Config{RSAKeyBits: 1024})
+	assert.Nil(t, err, "error creating emulator: %q", err)
+
+	ts := httptest.NewServer(emulator)
+	t.Cleanup(ts.Close)
+
+	transport := NewTransport(nil)
+	client, err := routerclient.NewRouterClient(ts.URL, "admin", "admin", routerclient.WithTransport(transport), routerclient.WithTimeout(time.Second))
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	return emulator, transport, client
+}
+
+func newLoggedInRouter(t *testing.T) (*routeremu.Emulator, *Transport, *routerclient.RouterClient) {
+	emulator, transport, client := newRouter(t)
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	return emulator, transport, client
+}
+
+// assertRecovers checks the fault affected only a single request
+func assertRecovers(t *testing.T, client *routerclient.RouterClient) {
+	signal, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats after fault: %q", err)
+	assert.Equal(t, float64(routeremu.DefaultSignal.RSRP), *signal.RSRP)
+}
+
+func TestLatency(t *testing.T) {
+	_, transport, client := newLoggedInRouter(t)
+
+	transport.ScriptPath(signalPath, Latency(50*time.Millisecond))
+	start := time.Now()
+	assertRecovers(t, client)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(50*time.Millisecond))
+
+	transport.ScriptPath(signalPath, Latency(time.Minute))
+	_, err := client.GetSignalStats()
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+
+	assertRecovers(t, client)
+}
+
+func TestConnectionReset(t *testing.T) {
+	_, transport, client := newLoggedInRouter(t)
+
+	transport.ScriptPath(signalPath, Reset())
+	_, err := client.GetSignalStats()
+	assert.True(t, errors.Is(err, syscall.ECONNRESET), "unexpected error: %v", err)
+
+	assertRecovers(t, client)
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	_, transport, client := newLoggedInRouter(t)
+
+	transport.ScriptPath(signalPath, Truncate())
+	_, err := client.GetSignalStats()
+	assert.NotNil(t, err, "truncated XML should be reported")
+
+	assertRecovers(t, client)
+}
+
+func TestHTTPErrorStatus(t *testing.T) {
+	_, transport, client := newLoggedInRouter(t)
+
+	transport.ScriptPath(signalPath, Status(http.StatusBadGateway))
+	_, err := client.GetSignalStats()
+	var statusErr *routerclient.StatusError
+	assert.True(t, errors.As(err, &statusErr), "unexpected error: %v", err)
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+
+	transport.ScriptPath(signalPath, Status(http.StatusServiceUnavailable))
+	_, err = client.GetSignalStats()
+	assert.True(t, errors.Is(err, routerclient.ErrBusy), "unexpected error: %v", err)
+
+	assertRecovers(t, client)
+}
+
+func TestMissingTokens(t *testing.T) {
+	emulator, transport, client := newRouter(t)
+	transport.AddRule(Rule{Probability: 1, Fault: DropToken()})
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in without tokens in headers: %q", err)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting without tokens in headers: %q", err)
+	assert.Equal(t, 1, emulator.Stats().Reboots)
+}
+
+func TestAPIErrors(t *testing.T) {
+	emulator, transport, client := newLoggedInRouter(t)
+
+	transport.ScriptPath(signalPath, APIError(100004))
+	_, err := client.GetSignalStats()
+	assert.True(t, errors.Is(err, routerclient.ErrBusy), "unexpected error: %v", err)
+
+	transport.ScriptPath(signalPath, APIError(125002))
+	assertRecovers(t, client)
+	assert.Equal(t, 2, emulator.Stats().Logins, "client should log in again after session error")
+}
+
+func TestFaultsDuringLogin(t *testing.T) {
+	emulator, transport, client := newRouter(t)
+
+	for _, fault := range []Fault{Reset(), Truncate(), Status(http.StatusInternalServerError), APIError(100004)} {
+		transport.ScriptPath("/api/user/challenge_login", fault)
+		err := client.Login()
+		assert.NotNil(t, err, "login should fail with %s", fault)
+	}
+
+	transport.ScriptPath("/api/user/authentication_login", Truncate())
+	err := client.Login()
+	assert.NotNil(t, err, "login should fail with truncated response")
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assertRecovers(t, client)
+	assert.Equal(t, 2, emulator.Stats().Logins)
+}
+
+func TestProbabilisticFaults(t *testing.T) {
+	_, transport, client := newLoggedInRouter(t)
+	transport.Seed(1)
+	transport.AddRule(Rule{Method: "GET", Path: signalPath, Probability: 0.3, Fault: Reset()})
+	transport.AddRule(Rule{Method: "GET", Path: signalPath, Probability: 0.3, Fault: Truncate()})
+
+	failures := 0
+	for i := 0; i < 50; i++ {
+		if _, err := client.GetSignalStats(); err != nil {
+			failures++
+		}
+	}
+
+	injected := transport.Injected()
+	assert.Equal(t, len(injected), failures, "every injected fault should fail exactly one request")
+	assert.Greater(t, failures, 0)
+	assert.Less(t, failures, 50)
+	for _, i := range injected {
+		assert.Equal(t, signalPath, i.Path)
+	}
+}
+
+func TestScriptIsAppliedInOrder(t *testing.T) {
+	_, transport, client := newLoggedInRouter(t)
+	transport.Script(None(), Reset())
+
+	_, err := client.GetSignalStats()
+	assert.Nil(t, err, "first request should not be affected: %q", err)
+
+	_, err = client.GetSignalStats()
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []Injection{{Method: "GET", Path: signalPath, Fault: Reset()}}, transport.Injected())
+	assertRecovers(t, client)
+}
@@ -1,67 +1,811 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/mkorz/b618reboot-go/cassette"
+	"github.com/mkorz/b618reboot-go/config"
+	"github.com/mkorz/b618reboot-go/credentials"
 	"github.com/mkorz/b618reboot-go/routerclient"
+	"github.com/mkorz/b618reboot-go/routeremu"
+	"github.com/mkorz/b618reboot-go/xmljson"
+	"golang.org/x/term"
 )
 
+// exitLockedOut is the exit code used when router refuses to log in after too many failed attempts
+const exitLockedOut = 2
+
 type mandatoryFlags struct {
-	RouterURL *string
-	Username  *string
-	Password  *string
-	FlagSet   *flag.FlagSet
+	RouterURL     *string
+	Username      *string
+	Password      *string
+	PasswordFile  *string
+	PasswordCmd   *string
+	PasswordStdin *bool
+	PasswordMode  *string
+	SessionCache  *string
+	Router        *string
+	ConfigPath    *string
+	Credentials   *string
+	Record        *string
+	FlagSet       *flag.FlagSet
 }
 
 func newFlagSet(name string) mandatoryFlags {
 	mf := mandatoryFlags{}
 	mf.FlagSet = flag.NewFlagSet(name, flag.ExitOnError)
 
-	mf.RouterURL = mf.FlagSet.String("url", os.Getenv("ROUTER_URL"), "router url ip or name (http://xxx.xxx.xx.xxx)")
-	mf.Username = mf.FlagSet.String("username", os.Getenv("ROUTER_USERNAME"), "username for router account")
-	mf.Password = mf.FlagSet.String("password", os.Getenv("ROUTER_PASSWORD"), "password for router account")
+	mf.RouterURL = mf.FlagSet.String("url", "", "router url ip or name (http://xxx.xxx.xx.xxx), env ROUTER_URL")
+	mf.Username = mf.FlagSet.String("username", "", "username for router account, env ROUTER_USERNAME")
+	mf.Password = mf.FlagSet.String("password", "", "password for router account, env ROUTER_PASSWORD")
+	mf.PasswordFile = mf.FlagSet.String("password-file", "", "file with password for router account, - for stdin, env ROUTER_PASSWORD_FILE")
+	mf.PasswordCmd = mf.FlagSet.String("password-cmd", "", "command printing password for router account, env ROUTER_PASSWORD_CMD")
+	mf.PasswordStdin = mf.FlagSet.Bool("password-stdin", false, "read password for router account from stdin")
+	mf.PasswordMode = mf.FlagSet.String("password-mode", "", "how password is sent to router: auto, scram, sha256 or base64, env ROUTER_PASSWORD_MODE (default \"auto\")")
+	mf.SessionCache = mf.FlagSet.String("session-cache", "", "file to keep router session in between runs, env ROUTER_SESSION_CACHE")
+	mf.Router = mf.FlagSet.String("router", "", "name of router profile from config file, env ROUTER_PROFILE")
+	mf.ConfigPath = addConfigFlag(mf.FlagSet)
+	mf.Credentials = addCredentialsFlag(mf.FlagSet)
+	mf.Record = mf.FlagSet.String("record", "", "record traffic with router to file, with passwords and identifiers redacted")
 	return mf
 }
 
+func addConfigFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", getenv("ROUTER_CONFIG", config.DefaultPath()), "config file with router profiles, env ROUTER_CONFIG")
+}
+
+func addCredentialsFlag(fs *flag.FlagSet) *string {
+	return fs.String("credentials", getenv("ROUTER_CREDENTIALS", credentials.DefaultStorePath()), "encrypted credential store, env ROUTER_CREDENTIALS")
+}
+
+// resolve fills in values not given with flags. The order of precedence is:
+// flags, environment variables, router profile from config file, defaults.
+func (mf mandatoryFlags) resolve() error {
+	set := map[string]bool{}
+	mf.FlagSet.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	cfg, err := config.Load(*mf.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if !set["router"] {
+		*mf.Router = os.Getenv("ROUTER_PROFILE")
+	}
+
+	profile, err := cfg.Profile(*mf.Router)
+	if err != nil {
+		return err
+	}
+
+	err = profile.Validate()
+	if err != nil {
+		return fmt.Errorf("router profile %q: %w", *mf.Router, err)
+	}
+
+	settings := []struct {
+		value   *string
+		flag    string
+		env     string
+		profile string
+		def     string
+	}{
+		{mf.RouterURL, "url", "ROUTER_URL", profile.URL, ""},
+		{mf.Username, "username", "ROUTER_USERNAME", profile.Username, ""},
+		{mf.PasswordMode, "password-mode", "ROUTER_PASSWORD_MODE", profile.PasswordMode, "auto"},
+		{mf.SessionCache, "session-cache", "ROUTER_SESSION_CACHE", profile.SessionCache, ""},
+	}
+
+	for _, s := range settings {
+		switch {
+		case set[s.flag]:
+		case os.Getenv(s.env) != "":
+			*s.value = os.Getenv(s.env)
+		case s.profile != "":
+			*s.value = s.profile
+		default:
+			*s.value = s.def
+		}
+	}
+
+	// password sources are resolved together, so that e.g. -password-file overrides ROUTER_PASSWORD
+	passwordSources := []struct {
+		value   *string
+		flag    string
+		env     string
+		profile string
+	}{
+		{mf.Password, "password", "ROUTER_PASSWORD", profile.Password},
+		{mf.PasswordFile, "password-file", "ROUTER_PASSWORD_FILE", profile.PasswordFile},
+		{mf.PasswordCmd, "password-cmd", "ROUTER_PASSWORD_CMD", profile.PasswordCmd},
+	}
+
+	fromFlags, fromEnv := set["password-stdin"], false
+	for _, s := range passwordSources {
+		fromFlags = fromFlags || set[s.flag]
+		fromEnv = fromEnv || os.Getenv(s.env) != ""
+	}
+
+	for _, s := range passwordSources {
+		switch {
+		case fromFlags:
+		case fromEnv:
+			*s.value = os.Getenv(s.env)
+		default:
+			*s.value = s.profile
+		}
+	}
+
+	return nil
+}
+
+// credentialsProvider returns provider for the password source given,
+// password in router URL, credential store and netrc file are used if there is none
+func (mf mandatoryFlags) credentialsProvider() credentials.Provider {
+	switch {
+	case *mf.PasswordStdin || *mf.PasswordFile == "-":
+		return credentials.Reader(os.Stdin)
+	case *mf.Password != "":
+		return credentials.Static(*mf.Password)
+	case *mf.PasswordFile != "":
+		return credentials.File(*mf.PasswordFile)
+	case *mf.PasswordCmd != "":
+		return credentials.Command(*mf.PasswordCmd)
+	default:
+		return credentials.Chain(
+			credentials.URL(*mf.RouterURL),
+			credentials.StoreProvider(*mf.Credentials, storePassphrase),
+			credentials.Netrc(credentials.DefaultNetrcPath()),
+		)
+	}
+}
+
+// storePassphrase returns passphrase of the credential store from environment, asking for it if not set
+func storePassphrase() ([]byte, error) {
+	if p := os.Getenv("ROUTER_CREDENTIALS_PASSPHRASE"); p != "" {
+		return []byte(p), nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, errors.New("credential store is locked, set ROUTER_CREDENTIALS_PASSPHRASE")
+	}
+
+	return readSecret("Credential store passphrase: ")
+}
+
+// readSecret reads a line from stdin, without echo if it is a terminal
+func readSecret(prompt string) ([]byte, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	return term.ReadPassword(int(os.Stdin.Fd()))
+}
+
+func getenv(key string, defaultValue string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func newRouterClient(mf mandatoryFlags) (*routerclient.RouterClient, error) {
+	err := mf.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := mf.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	username := *mf.Username
+	if username == "" {
+		username = urlUsername(*mf.RouterURL)
+	}
+
+	creds, err := mf.credentialsProvider().Lookup(credentials.Request{Host: hostname(*mf.RouterURL), Username: username})
+	if errors.Is(err, credentials.ErrNotFound) {
+		return nil, errors.New("no password given, use -password, -password-file, -password-stdin, -password-cmd or netrc file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return routerclient.NewRouterClient(*mf.RouterURL, creds.Username, creds.Password, opts...)
+}
+
+// newAnonymousClient creates client from the flags without credentials, for requests not needing login
+func newAnonymousClient(mf mandatoryFlags) (*routerclient.RouterClient, error) {
+	err := mf.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := mf.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return routerclient.NewAnonymousRouterClient(*mf.RouterURL, opts...)
+}
+
+// clientOptions returns options of the client set with resolved flags
+func (mf mandatoryFlags) clientOptions() ([]routerclient.Option, error) {
+	passwordMode, err := routerclient.ParsePasswordMode(*mf.PasswordMode)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []routerclient.Option{routerclient.WithPasswordMode(passwordMode)}
+	if *mf.SessionCache != "" {
+		opts = append(opts, routerclient.WithSessionCache(*mf.SessionCache))
+	}
+	if *mf.Record != "" {
+		opts = append(opts, routerclient.WithTransport(cassette.NewRecorder(*mf.Record, nil)))
+	}
+
+	return opts, nil
+}
+
+// newLoggedInClient creates client from the flags and logs in to router
+func newLoggedInClient(mf mandatoryFlags) (*routerclient.RouterClient, error) {
+	client, err := newRouterClient(mf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Login()
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	return client, nil
+}
+
+func signalStats(mf mandatoryFlags) error {
+	client, err := newLoggedInClient(mf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	stats, err := client.GetSignalStats()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*mf.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.Quality.Validate()
+	if err != nil {
+		return fmt.Errorf("quality: %w", err)
+	}
+
+	out, err := json.Marshal(struct {
+		routerclient.Signal
+		Quality routerclient.Quality `json:"Quality"`
+	}{stats, routerclient.AssessQuality(stats, cfg.Quality)})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
+
+func status(mf mandatoryFlags) error {
+	client, err := newLoggedInClient(mf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
+
+// trafficCmd prints traffic statistics and data plan, changes the plan or clears the statistics
+func trafficCmd(args []string) error {
+	usage := errors.New("usage: traffic [plan [-start-day DAY] [-limit LIMIT] [-threshold PERCENT] [-enabled=true|false] | clear]")
+
+	command := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	if command != "" && command != "plan" && command != "clear" {
+		return usage
+	}
+
+	mf := newFlagSet("traffic")
+	startDay := mf.FlagSet.Int("start-day", 0, "day of month billing month begins, 1-31")
+	limit := mf.FlagSet.String("limit", "", "monthly data limit, e.g. 500MB or 10GB")
+	threshold := mf.FlagSet.Int("threshold", 0, "percentage of data limit router warns at")
+	enabled := mf.FlagSet.Bool("enabled", false, "enable or disable (-enabled=false) monthly statistics and data limit, kept as is if not given")
+	mf.FlagSet.Parse(args)
+	if mf.FlagSet.NArg() != 0 {
+		return usage
+	}
+
+	set := map[string]bool{}
+	mf.FlagSet.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	changesPlan := set["start-day"] || set["limit"] || set["threshold"] || set["enabled"]
+	if changesPlan && command != "plan" {
+		return errors.New("-start-day, -limit, -threshold and -enabled can be used only with plan")
+	}
+
+	client, err := newLoggedInClient(mf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if command == "clear" {
+		return client.ClearTraffic()
+	}
+
+	plan, err := client.GetDataPlan()
+	if err != nil {
+		return err
+	}
+
+	var out interface{} = plan
+	switch {
+	case changesPlan:
+		if set["start-day"] {
+			plan.StartDay = *startDay
+		}
+		if set["limit"] {
+			plan.DataLimit, err = routerclient.ParseDataLimit(*limit)
+			if err != nil {
+				return err
+			}
+		} else if len(plan.Warnings) != 0 {
+			// unreadable limit would be overwritten with 0
+			return fmt.Errorf("router reported unexpected data plan (%s), set it with -limit", strings.Join(plan.Warnings, ", "))
+		}
+		plan.Warnings = nil
+		if set["threshold"] {
+			plan.Threshold = *threshold
+		}
+		if set["enabled"] {
+			plan.Enabled = *enabled
+		}
+
+		err = client.SetDataPlan(plan)
+		if err != nil {
+			return err
+		}
+		out = plan
+
+	case command == "":
+		stats, err := client.GetTrafficStatistics()
+		if err != nil {
+			return err
+		}
+
+		month, err := client.GetMonthStatistics()
+		if err != nil {
+			return err
+		}
+
+		out = struct {
+			Statistics routerclient.TrafficStatistics `json:"Statistics"`
+			Month      routerclient.MonthStatistics   `json:"Month"`
+			Plan       routerclient.DataPlan          `json:"Plan"`
+			// Usage is the percentage of the data limit used in the month
+			Usage *float64 `json:"Usage"`
+		}{stats, month, plan, plan.Usage(month)}
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
+
+// infoCmd prints information about the device
+func infoCmd(args []string) error {
+	mf := newFlagSet("info")
+	maskIDs := mf.FlagSet.Bool("mask", false, "mask serial number, IMEI, IMSI, ICCID and MSISDN")
+	basic := mf.FlagSet.Bool("basic", false, "print only basic information, available without logging in")
+	mf.FlagSet.Parse(args)
+
+	var info interface{}
+	if *basic {
+		client, err := newAnonymousClient(mf)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		info, err = client.GetBasicDeviceInfo()
+		if err != nil {
+			return err
+		}
+	} else {
+		client, err := newLoggedInClient(mf)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		deviceInfo, err := client.GetDeviceInfo()
+		if err != nil {
+			return err
+		}
+		if *maskIDs {
+			deviceInfo = deviceInfo.Masked()
+		}
+		info = deviceInfo
+	}
+
+	out, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
+
+func reboot(mf mandatoryFlags) error {
+	client, err := newLoggedInClient(mf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Reboot()
+}
+
+// apiCmd sends request to any API endpoint and prints the response
+func apiCmd(args []string) error {
+	usage := errors.New("usage: api get|post PATH [-data DATA|@FILE|@-] [-encrypt] [-json]")
+	if len(args) == 0 || args[0] != "get" && args[0] != "post" {
+		return usage
+	}
+
+	mf := newFlagSet("api")
+	data := mf.FlagSet.String("data", "", "request body for post, @FILE reads it from file, @- from stdin")
+	encrypt := mf.FlagSet.Bool("encrypt", false, "encrypt post body with router public key, as required for passwords and PIN codes")
+	asJSON := mf.FlagSet.Bool("json", false, "convert XML response to JSON")
+
+	// flags are accepted both before and after the path
+	mf.FlagSet.Parse(args[1:])
+	if mf.FlagSet.NArg() == 0 {
+		return usage
+	}
+	path := mf.FlagSet.Arg(0)
+	mf.FlagSet.Parse(mf.FlagSet.Args()[1:])
+	if mf.FlagSet.NArg() != 0 {
+		return usage
+	}
+
+	var body []byte
+	switch {
+	case args[0] == "get" && *data != "":
+		return errors.New("-data can be used only with post")
+	case args[0] == "get" && *encrypt:
+		return errors.New("-encrypt can be used only with post")
+	case *data == "@-":
+		var err error
+		body, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+	case strings.HasPrefix(*data, "@"):
+		var err error
+		body, err = ioutil.ReadFile((*data)[1:])
+		if err != nil {
+			return err
+		}
+	default:
+		body = []byte(*data)
+	}
+
+	client, err := newLoggedInClient(mf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var response []byte
+	switch {
+	case args[0] == "get":
+		response, err = client.Get(path)
+	case *encrypt:
+		response, err = client.PostEncrypted(path, body)
+	default:
+		response, err = client.Post(path, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		response, err = xmljson.Convert(response)
+		if err != nil {
+			return err
+		}
+		response = append(response, '\n')
+	}
+
+	_, err = os.Stdout.Write(response)
+	return err
+}
+
+// configCmd lists or validates router profiles from the config file
+func configCmd(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	configPath := addConfigFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || fs.Arg(0) != "list" && fs.Arg(0) != "validate" {
+		return errors.New("usage: config [-config FILE] list|validate")
+	}
+
+	if *configPath == "" {
+		return errors.New("config file location unknown, use -config")
+	}
+
+	if _, err := os.Stat(*configPath); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if fs.Arg(0) == "validate" {
+		err = cfg.Validate()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("config %s is valid, %d router profile(s)\n", *configPath, len(cfg.Routers))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tURL\tUSERNAME\tDEFAULT")
+	for _, name := range cfg.Names() {
+		profile := cfg.Routers[name]
+		if profile == nil {
+			profile = &config.Profile{}
+		}
+
+		def := ""
+		if name == cfg.Default {
+			def = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, profile.URL, profile.Username, def)
+	}
+
+	return w.Flush()
+}
+
+// credentialsCmd manages passwords in the encrypted credential store
+func credentialsCmd(args []string) error {
+	fs := flag.NewFlagSet("credentials", flag.ExitOnError)
+	path := addCredentialsFlag(fs)
+	fs.Parse(args)
+
+	usage := errors.New("usage: credentials [-credentials FILE] add|remove HOST USERNAME | list")
+	if fs.NArg() == 0 {
+		return usage
+	}
+
+	command := fs.Arg(0)
+	switch {
+	case command == "list" && fs.NArg() == 1:
+	case (command == "add" || command == "remove") && fs.NArg() == 3:
+	default:
+		return usage
+	}
+
+	if *path == "" {
+		return errors.New("credential store location unknown, use -credentials")
+	}
+
+	_, statErr := os.Stat(*path)
+	if command != "add" && statErr != nil {
+		return statErr
+	}
+
+	passphrase, err := storePassphrase()
+	if err != nil {
+		return err
+	}
+
+	// new store is created, make sure the passphrase was not mistyped
+	if os.IsNotExist(statErr) && os.Getenv("ROUTER_CREDENTIALS_PASSPHRASE") == "" {
+		repeated, err := readSecret("Repeat passphrase: ")
+		if err != nil {
+			return err
+		}
+		if string(repeated) != string(passphrase) {
+			return errors.New("passphrases do not match")
+		}
+	}
+
+	store, err := credentials.OpenStore(*path, passphrase)
+	if err != nil {
+		return err
+	}
+
+	host, username := hostname(fs.Arg(1)), fs.Arg(2)
+
+	switch command {
+	case "list":
+		for _, e := range store.Entries() {
+			fmt.Printf("%s@%s\n", e.Username, e.Host)
+		}
+		return nil
+
+	case "add":
+		password, err := readSecret(fmt.Sprintf("Password for %s@%s: ", username, host))
+		if err != nil {
+			return err
+		}
+		if len(password) == 0 {
+			return errors.New("password cannot be empty")
+		}
+		store.Set(host, username, string(password))
+
+	case "remove":
+		if !store.Remove(host, username) {
+			return fmt.Errorf("no password stored for %s@%s", username, host)
+		}
+	}
+
+	return store.Save()
+}
+
+// hostname returns host name from router url, or the argument itself if it is not an url
+func hostname(router string) string {
+	u, err := url.Parse(router)
+	if err != nil || u.Host == "" {
+		return router
+	}
+
+	return u.Hostname()
+}
+
+// urlUsername returns username embedded in router URL, empty if there is none
+func urlUsername(router string) string {
+	u, err := url.Parse(router)
+	if err != nil || u.User == nil {
+		return ""
+	}
+
+	return u.User.Username()
+}
+
+// emulateCmd serves emulated router web API
+func emulateCmd(args []string) error {
+	fs := flag.NewFlagSet("emulate", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "address to listen on")
+	username := fs.String("username", "admin", "username for router account")
+	password := fs.String("password", "admin", "password for router account")
+	rebootDuration := fs.Duration("reboot-duration", time.Minute, "time router is offline after reboot")
+	sessionTimeout := fs.Duration("session-timeout", 5*time.Minute, "time after idle session is dropped")
+	randomWalk := fs.Bool("random-walk", true, "change signal values with every request")
+	seed := fs.Int64("seed", 0, "seed of signal random walk, 0 for random")
+	fs.Parse(args)
+
+	emulator, err := routeremu.New(routeremu.Config{
+		Username:       *username,
+		Password:       *password,
+		RandomWalk:     *randomWalk,
+		Seed:           *seed,
+		RebootDuration: *rebootDuration,
+		SessionTimeout: *sessionTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "emulating router at http://%s\n", listener.Addr())
+	return http.Serve(listener, emulator)
+}
+
 func main() {
 	signalStatsCmdFlags := newFlagSet("signal-stats")
 	rebootCmdFlags := newFlagSet("reboot")
+	statusCmdFlags := newFlagSet("status")
 
 	if len(os.Args) < 2 || os.Args[1] == "help" {
-		fmt.Println("one of the following commands is required: signal-stats, reboot")
+		fmt.Println("one of the following commands is required: signal-stats, status, traffic, reboot, info, api, config, credentials, emulate")
 		os.Exit(1)
 	}
 
-	var client *routerclient.RouterClient
 	var err error
 	switch os.Args[1] {
 	case "signal-stats":
 		signalStatsCmdFlags.FlagSet.Parse(os.Args[2:])
-		client, err = routerclient.NewRouterClient(*signalStatsCmdFlags.RouterURL, *signalStatsCmdFlags.Username, *signalStatsCmdFlags.Password)
-		if err != nil {
-			panic(err)
-		}
-		err = client.Login()
-		stats, _ := client.GetSignalStats()
-		out, _ := json.Marshal(stats)
+		err = signalStats(signalStatsCmdFlags)
 
-		fmt.Println(string(out))
+	case "status":
+		statusCmdFlags.FlagSet.Parse(os.Args[2:])
+		err = status(statusCmdFlags)
 
 	case "reboot":
 		rebootCmdFlags.FlagSet.Parse(os.Args[2:])
-		client, err = routerclient.NewRouterClient(*rebootCmdFlags.RouterURL, *rebootCmdFlags.Username, *rebootCmdFlags.Password)
-		if err != nil {
-			panic(err)
-		}
-		err = client.Login()
-		client.Reboot()
+		err = reboot(rebootCmdFlags)
+
+	case "traffic":
+		err = trafficCmd(os.Args[2:])
+
+	case "info":
+		err = infoCmd(os.Args[2:])
+
+	case "api":
+		err = apiCmd(os.Args[2:])
+
+	case "config":
+		err = configCmd(os.Args[2:])
+
+	case "credentials":
+		err = credentialsCmd(os.Args[2:])
+
+	case "emulate":
+		err = emulateCmd(os.Args[2:])
 
 	default:
 		fmt.Printf("invalid command: %q\n", os.Args[1])
 		os.Exit(1)
 	}
 
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		var lockoutErr *routerclient.LockoutError
+		if errors.As(err, &lockoutErr) {
+			os.Exit(exitLockedOut)
+		}
+		os.Exit(1)
+	}
 }
@@ -1,14 +1,13 @@
 package routerclient
 
 import (
-	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/pbkdf2"
@@ -42,44 +41,7 @@ func generateClientNonce() (string, error) {
 	return uuid1 + uuid2, nil
 }
 
-func (c *RouterClient) updateVerificationTokenFromHeaders(httpResponse *http.Response) error {
-	token := httpResponse.Header[requestVerificationToken]
-	if token == nil {
-		return fmt.Errorf("missing %s token in the response", requestVerificationToken)
-	}
-
-	c.requestVerificationToken = token[0][0:32]
-
-	return nil
-}
-
-func (c *RouterClient) getServerToken() error {
-	resp, err := c.client.Get(c.routerURL + tokenURL)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	type Response struct {
-		Token string `xml:"token"`
-	}
-
-	responseData, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	v := Response{}
-	err = xml.Unmarshal(responseData, &v)
-	if err != nil {
-		return err
-	}
-
-	c.requestVerificationToken = v.Token[32:]
-	return nil
-}
-
-func (c *RouterClient) challengeLogin(clientNonce string) (int, string, string, error) {
+func (c *RouterClient) challengeLogin(ctx context.Context, clientNonce string) (int, string, string, error) {
 	type ChallengeLoginRequest struct {
 		XMLName    xml.Name `xml:"request"`
 		Username   string   `xml:"username"`
@@ -100,10 +62,7 @@ func (c *RouterClient) challengeLogin(clientNonce string) (int, string, string,
 
 	challengeLoginWithHeader := append([]byte(xml.Header), challengeLogin...)
 
-	req, err := http.NewRequest("POST", c.routerURL+challengeLoginURL, bytes.NewReader(challengeLoginWithHeader))
-	req.Header.Add("Content-Type", "text/html")
-	req.Header.Add(requestVerificationToken, c.requestVerificationToken)
-	resp, err := c.client.Do(req)
+	responseData, err := c.do(ctx, "POST", challengeLoginURL, "text/html", challengeLoginWithHeader)
 	if err != nil {
 		return 0, "", "", err
 	}
@@ -114,13 +73,6 @@ func (c *RouterClient) challengeLogin(clientNonce string) (int, string, string,
 		Salt        string `xml:"salt"`
 	}
 
-	responseData, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, "", "", err
-	}
-
-	c.updateVerificationTokenFromHeaders(resp)
-
 	v := ChallengeLoginResponse{}
 	err = xml.Unmarshal(responseData, &v)
 	if err != nil {
@@ -162,7 +114,7 @@ func calculateClientProof(password string, clientNonce string, iterations int, s
 	return hex.EncodeToString(clientProof), nil
 }
 
-func (c *RouterClient) authLogin(clientNonce string, iterations int, serverNonce string, salt string) error {
+func (c *RouterClient) authLogin(ctx context.Context, clientNonce string, iterations int, serverNonce string, salt string) error {
 	type AuthLoginRequest struct {
 		XMLName     xml.Name `xml:"request"`
 		ClientProof string   `xml:"clientproof"`
@@ -170,6 +122,9 @@ func (c *RouterClient) authLogin(clientNonce string, iterations int, serverNonce
 	}
 
 	clientProof, err := calculateClientProof(c.password, clientNonce, iterations, serverNonce, salt)
+	if err != nil {
+		return err
+	}
 
 	authLoginRequest := &AuthLoginRequest{
 		ClientProof: clientProof,
@@ -183,46 +138,135 @@ func (c *RouterClient) authLogin(clientNonce string, iterations int, serverNonce
 
 	authLoginWithHeader := append([]byte(xml.Header), authLogin...)
 
-	req, err := http.NewRequest("POST", c.routerURL+authLoginURL, bytes.NewReader(authLoginWithHeader))
-	req.Header.Add("Content-Type", "text/html")
-	req.Header.Add(requestVerificationToken, c.requestVerificationToken)
-	resp, err := c.client.Do(req)
+	responseData, err := c.do(ctx, "POST", authLoginURL, "text/html", authLoginWithHeader)
 	if err != nil {
 		return err
 	}
 
-	c.updateVerificationTokenFromHeaders(resp)
+	// serversignature and rsapubkeysignature are not verified, the way router calculates them
+	// has not been confirmed with traffic of a physical router
+	type AuthLoginResponse struct {
+		RSAN string `xml:"rsan"`
+		RSAE string `xml:"rsae"`
+	}
 
-	return nil
+	v := AuthLoginResponse{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return err
+	}
 
+	if v.RSAN == "" {
+		return nil
+	}
+
+	publicKey, err := parsePublicKey(v.RSAN, v.RSAE)
+	if err != nil {
+		return err
+	}
+
+	c.setPublicKey(publicKey)
+
+	return nil
 }
 
-//Login initialize the sessio and logs in to router
-func (c *RouterClient) Login() error {
-	err := c.initSession()
+func (c *RouterClient) scramLogin(ctx context.Context) error {
+	clientNonce, err := generateClientNonce()
 	if err != nil {
 		return err
 	}
 
-	err = c.getServerToken()
+	iterations, serverNonce, salt, err := c.challengeLogin(ctx, clientNonce)
 	if err != nil {
 		return err
 	}
 
-	clientNonce, err := generateClientNonce()
+	return c.authLogin(ctx, clientNonce, iterations, serverNonce, salt)
+}
+
+// Login initialize the sessio and logs in to router
+func (c *RouterClient) Login() error {
+	return c.LoginContext(context.Background())
+}
+
+// LoginContext is like Login, but every request made during the login is bound to ctx
+func (c *RouterClient) LoginContext(ctx context.Context) error {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.sessionCache != "" && c.restoreSession(ctx) {
+		return nil
+	}
+
+	err := c.login(ctx)
 	if err != nil {
 		return err
 	}
 
-	iterations, serverNonce, salt, err := c.challengeLogin(clientNonce)
+	if c.sessionCache != "" {
+		if err := c.saveSession(); err != nil {
+			c.logf("saving session failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// login performs the login, caller must hold sessionMu for writing
+func (c *RouterClient) login(ctx context.Context) error {
+	if c.password == "" {
+		return ErrNoCredentials
+	}
+
+	if err := c.checkLockout(); err != nil {
+		return err
+	}
+
+	c.resetTokens()
+	c.setPublicKey(nil)
+
+	err := c.initSession(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = c.authLogin(clientNonce, iterations, serverNonce, salt)
+	err = c.getServerToken(ctx)
 	if err != nil {
 		return err
 	}
 
+	// firmware not reporting login state can still be logged in to
+	var state *LoginState
+	loginState, err := c.loginState(ctx)
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		state = &loginState
+		c.setRSAPadding(loginState.RSAPaddingType)
+		if err := c.lockoutFromState(loginState); err != nil {
+			return err
+		}
+	case errors.As(err, &apiErr):
+		c.logf("login state not available: %v", err)
+	default:
+		return err
+	}
+
+	mode := c.detectPasswordMode(state)
+
+	if mode == PasswordModeSCRAM {
+		err = c.scramLogin(ctx)
+	} else {
+		err = c.passwordLogin(ctx, mode)
+	}
+	if err != nil {
+		return c.loginFailure(ctx, err)
+	}
+
+	c.mu.Lock()
+	c.loggedIn = true
+	c.generation++
+	c.mu.Unlock()
+
 	return nil
 }
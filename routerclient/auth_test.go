@@ -1,11 +1,14 @@
 package routerclient
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -32,10 +35,10 @@ func TestCanRetrieveToken(t *testing.T) {
 
 	assert.Nil(t, err, "error creating client: %q", err)
 
-	err = client.getServerToken()
+	err = client.getServerToken(context.Background())
 
 	assert.Nil(t, err, "error retrieving token %q", err)
-	assert.Equal(t, expectedToken, client.requestVerificationToken, "invalid token returned, expected")
+	assert.Equal(t, []string{expectedToken}, client.tokens, "invalid token returned, expected")
 }
 
 func TestCookieIsSendBack(t *testing.T) {
@@ -64,8 +67,8 @@ func TestCookieIsSendBack(t *testing.T) {
 
 	client, err := NewRouterClient(ts.URL, "user", "pass")
 	assert.Nil(t, err, "error creating client: %q", err)
-	client.initSession()
-	client.getServerToken()
+	client.initSession(context.Background())
+	client.getServerToken(context.Background())
 }
 
 func TestNonceHasCorrectLength(t *testing.T) {
@@ -120,8 +123,8 @@ func TestCanDoChallengeLogin(t *testing.T) {
 
 	assert.Nil(t, err, "error creating client: %q", err)
 
-	client.requestVerificationToken = token
-	iterations, serverNonce, salt, err := client.challengeLogin(clientNonce)
+	client.tokens = []string{token}
+	iterations, serverNonce, salt, err := client.challengeLogin(context.Background(), clientNonce)
 
 	assert.Nil(t, err, "error retrieving token %q", err)
 
@@ -172,15 +175,18 @@ func TestCanDoAuthLogin(t *testing.T) {
 
 	defer ts.Close()
 	client, err := NewRouterClient(ts.URL, "admin", "MySecretPassword")
-	client.requestVerificationToken = token
+	client.tokens = []string{token}
 
 	assert.Nil(t, err, "error creating client: %q", err)
 
-	err = client.authLogin(clientNonce, 100, serverNonce, salt)
+	err = client.authLogin(context.Background(), clientNonce, 100, serverNonce, salt)
 
 	assert.Nil(t, err, "error retrieving token %q", err)
 
-	assert.Equal(t, "25ae2067cf278b183daab21a32d133e5", client.requestVerificationToken)
+	assert.Equal(t, []string{"25ae2067cf278b183daab21a32d133e5"}, client.tokens)
+	assert.NotNil(t, client.publicKey, "public key should be stored after login")
+	assert.Equal(t, 65537, client.publicKey.E)
+	assert.Equal(t, 256, client.publicKey.Size())
 }
 
 func TestCanCalculateClientProof(t *testing.T) {
@@ -195,3 +201,26 @@ func TestCanCalculateClientProof(t *testing.T) {
 
 	assert.Equal(t, expectedClientProof, res)
 }
+
+func TestLoginIsCancelledWithContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/":
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvzS7xTD7LGjRhXAtLCQRYWUKc5YdaRuzJJ</token></response>")
+		default:
+			ioutil.ReadAll(r.Body)
+			<-r.Context().Done()
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = client.LoginContext(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "deadline exceeded error expected, got %q", err)
+}
@@ -0,0 +1,239 @@
+package routerclient
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnknownChannel is returned when channel number does not belong to any known band
+var ErrUnknownChannel = errors.New("channel number not in any known band")
+
+// DuplexMode is how uplink and downlink share the band
+type DuplexMode string
+
+// Duplex modes of bands
+const (
+	DuplexFDD DuplexMode = "FDD"
+	DuplexTDD DuplexMode = "TDD"
+	// DuplexSDL is supplemental downlink, band has no uplink
+	DuplexSDL DuplexMode = "SDL"
+)
+
+// Carrier is band and centre frequencies derived from channel numbers
+type Carrier struct {
+	Band   int        `json:"Band"`
+	Duplex DuplexMode `json:"Duplex"`
+	// centre frequencies in MHz, uplink is nil for SDL bands
+	Downlink *float64 `json:"Downlink"`
+	Uplink   *float64 `json:"Uplink"`
+}
+
+// lteBand is E-UTRA operating band from 3GPP TS 36.101 table 5.7.3-1, frequencies are in MHz
+type lteBand struct {
+	number                 int
+	duplex                 DuplexMode
+	dlLow, dlFirst, dlLast int
+	ulLow, ulFirst, ulLast int
+}
+
+// frequency of channel n in kHz is F_low + 100 kHz * (n - N_offs), N_offs being the first channel of the band
+func channelKHz(low int, first int, n int) int {
+	return low*1000 + 100*(n-first)
+}
+
+var lteBands = []lteBand{
+	{1, DuplexFDD, 2110, 0, 599, 1920, 18000, 18599},
+	{2, DuplexFDD, 1930, 600, 1199, 1850, 18600, 19199},
+	{3, DuplexFDD, 1805, 1200, 1949, 1710, 19200, 19949},
+	{4, DuplexFDD, 2110, 1950, 2399, 1710, 19950, 20399},
+	{5, DuplexFDD, 869, 2400, 2649, 824, 20400, 20649},
+	{7, DuplexFDD, 2620, 2750, 3449, 2500, 20750, 21449},
+	{8, DuplexFDD, 925, 3450, 3799, 880, 21450, 21799},
+	{12, DuplexFDD, 729, 5010, 5179, 699, 23010, 23179},
+	{13, DuplexFDD, 746, 5180, 5279, 777, 23180, 23279},
+	{14, DuplexFDD, 758, 5280, 5379, 788, 23280, 23379},
+	{17, DuplexFDD, 734, 5730, 5849, 704, 23730, 23849},
+	{18, DuplexFDD, 860, 5850, 5999, 815, 23850, 23999},
+	{19, DuplexFDD, 875, 6000, 6149, 830, 24000, 24149},
+	{20, DuplexFDD, 791, 6150, 6449, 832, 24150, 24449},
+	{25, DuplexFDD, 1930, 8040, 8689, 1850, 26040, 26689},
+	{26, DuplexFDD, 859, 8690, 9039, 814, 26690, 27039},
+	{28, DuplexFDD, 758, 9210, 9659, 703, 27210, 27659},
+	{32, DuplexSDL, 1452, 9920, 10359, 0, 0, -1},
+	{38, DuplexTDD, 2570, 37750, 38249, 2570, 37750, 38249},
+	{40, DuplexTDD, 2300, 38650, 39649, 2300, 38650, 39649},
+	{41, DuplexTDD, 2496, 39650, 41589, 2496, 39650, 41589},
+	{42, DuplexTDD, 3400, 41590, 43589, 3400, 41590, 43589},
+	{43, DuplexTDD, 3600, 43590, 45589, 3600, 43590, 45589},
+	{66, DuplexFDD, 2110, 66436, 67335, 1710, 131972, 132671},
+	{71, DuplexFDD, 617, 68586, 68935, 663, 133122, 133471},
+}
+
+func lteBandByDownlink(earfcn int) (lteBand, bool) {
+	for _, b := range lteBands {
+		if earfcn >= b.dlFirst && earfcn <= b.dlLast {
+			return b, true
+		}
+	}
+	return lteBand{}, false
+}
+
+func lteBandByUplink(earfcn int) (lteBand, bool) {
+	for _, b := range lteBands {
+		if b.duplex != DuplexSDL && earfcn >= b.ulFirst && earfcn <= b.ulLast {
+			return b, true
+		}
+	}
+	return lteBand{}, false
+}
+
+// Carrier derives LTE band and centre frequencies from EARFCN. If uplink EARFCN is not known,
+// it is derived from downlink one with the default duplex spacing of the band.
+func (e signalEARFCN) Carrier() (*Carrier, error) {
+	if e.Downlink == nil {
+		return nil, nil
+	}
+
+	band, ok := lteBandByDownlink(*e.Downlink)
+	if !ok {
+		return nil, fmt.Errorf("%w: EARFCN %d", ErrUnknownChannel, *e.Downlink)
+	}
+
+	dl := float64(channelKHz(band.dlLow, band.dlFirst, *e.Downlink)) / 1000
+	carrier := &Carrier{Band: band.number, Duplex: band.duplex, Downlink: &dl}
+	if band.duplex == DuplexSDL {
+		return carrier, nil
+	}
+
+	ulEARFCN := *e.Downlink - band.dlFirst + band.ulFirst
+	if e.Uplink != nil {
+		ulBand, ok := lteBandByUplink(*e.Uplink)
+		if !ok || ulBand.number != band.number {
+			return carrier, fmt.Errorf("uplink EARFCN %d is not in band %d of downlink EARFCN %d", *e.Uplink, band.number, *e.Downlink)
+		}
+		ulEARFCN = *e.Uplink
+	}
+
+	ul := float64(channelKHz(band.ulLow, band.ulFirst, ulEARFCN)) / 1000
+	carrier.Uplink = &ul
+	return carrier, nil
+}
+
+// nrRaster is a range of the global frequency raster from 3GPP TS 38.104 table 5.4.2.1-1
+type nrRaster struct {
+	first, last int
+	// stepKHz is the raster granularity, offsetKHz is the frequency of the first channel
+	stepKHz, offsetKHz int
+}
+
+var nrRasters = []nrRaster{
+	{0, 599999, 5, 0},
+	{600000, 2016666, 15, 3000000},
+	{2016667, 3279165, 60, 24250080},
+}
+
+// NRFrequency converts NR-ARFCN to frequency in MHz
+func NRFrequency(arfcn int) (float64, error) {
+	for _, r := range nrRasters {
+		if arfcn >= r.first && arfcn <= r.last {
+			return float64(r.offsetKHz+r.stepKHz*(arfcn-r.first)) / 1000, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: NR-ARFCN %d", ErrUnknownChannel, arfcn)
+}
+
+// nrBand is NR operating band with its downlink NR-ARFCN range, from 3GPP TS 38.104 table 5.4.2.3-1
+type nrBand struct {
+	number      int
+	duplex      DuplexMode
+	first, last int
+}
+
+var nrBands = []nrBand{
+	{1, DuplexFDD, 422000, 434000},
+	{2, DuplexFDD, 386000, 398000},
+	{3, DuplexFDD, 361000, 376000},
+	{5, DuplexFDD, 173800, 178800},
+	{7, DuplexFDD, 524000, 538000},
+	{8, DuplexFDD, 185000, 192000},
+	{20, DuplexFDD, 158200, 164200},
+	{28, DuplexFDD, 151600, 160600},
+	{38, DuplexTDD, 514000, 524000},
+	{40, DuplexTDD, 460000, 480000},
+	{41, DuplexTDD, 499200, 537999},
+	{66, DuplexFDD, 422000, 440000},
+	{71, DuplexFDD, 123400, 130400},
+	{77, DuplexTDD, 620000, 680000},
+	{78, DuplexTDD, 620000, 653333},
+	{79, DuplexTDD, 693334, 733333},
+	{257, DuplexTDD, 2054166, 2104165},
+	{258, DuplexTDD, 2016667, 2070832},
+	{260, DuplexTDD, 2229166, 2279165},
+	{261, DuplexTDD, 2070833, 2084999},
+}
+
+// NRBands returns numbers of NR bands downlink NR-ARFCN belongs to. NR bands overlap,
+// e.g. n78 is a part of n77, so there can be more than one, the narrowest one is the first.
+func NRBands(arfcn int) []int {
+	bands := []nrBand{}
+	for _, b := range nrBands {
+		if arfcn >= b.first && arfcn <= b.last {
+			bands = append(bands, b)
+		}
+	}
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].last-bands[i].first < bands[j].last-bands[j].first
+	})
+
+	numbers := make([]int, len(bands))
+	for i, b := range bands {
+		numbers[i] = b.number
+	}
+	return numbers
+}
+
+// NRCarrier is NR band and centre frequencies derived from NR-ARFCN
+type NRCarrier struct {
+	// Bands are all the bands downlink channel belongs to, the narrowest one first,
+	// duplex mode is the one of the narrowest band
+	Bands  []int      `json:"Bands"`
+	Duplex DuplexMode `json:"Duplex"`
+	// centre frequencies in MHz
+	Downlink *float64 `json:"Downlink"`
+	Uplink   *float64 `json:"Uplink"`
+}
+
+// Carrier derives NR bands and centre frequencies from NR-ARFCN
+func (e signalNRARFCN) Carrier() (*NRCarrier, error) {
+	if e.Downlink == nil {
+		return nil, nil
+	}
+
+	dl, err := NRFrequency(*e.Downlink)
+	if err != nil {
+		return nil, err
+	}
+
+	bands := NRBands(*e.Downlink)
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("%w: NR-ARFCN %d", ErrUnknownChannel, *e.Downlink)
+	}
+
+	carrier := &NRCarrier{Bands: bands, Downlink: &dl}
+	for _, b := range nrBands {
+		if b.number == bands[0] {
+			carrier.Duplex = b.duplex
+		}
+	}
+
+	if e.Uplink != nil {
+		ul, err := NRFrequency(*e.Uplink)
+		if err != nil {
+			return carrier, err
+		}
+		carrier.Uplink = &ul
+	}
+
+	return carrier, nil
+}
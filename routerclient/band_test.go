@@ -0,0 +1,80 @@
+package routerclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLTECarrier(t *testing.T) {
+	tests := []struct {
+		earfcn   signalEARFCN
+		expected *Carrier
+	}{
+		{signalEARFCN{Downlink: intPtr(3025), Uplink: intPtr(21025)}, &Carrier{Band: 7, Duplex: DuplexFDD, Downlink: floatPtr(2647.5), Uplink: floatPtr(2527.5)}},
+		{signalEARFCN{Downlink: intPtr(3025)}, &Carrier{Band: 7, Duplex: DuplexFDD, Downlink: floatPtr(2647.5), Uplink: floatPtr(2527.5)}},
+		{signalEARFCN{Downlink: intPtr(0)}, &Carrier{Band: 1, Duplex: DuplexFDD, Downlink: floatPtr(2110), Uplink: floatPtr(1920)}},
+		{signalEARFCN{Downlink: intPtr(6300)}, &Carrier{Band: 20, Duplex: DuplexFDD, Downlink: floatPtr(806), Uplink: floatPtr(847)}},
+		{signalEARFCN{Downlink: intPtr(38000), Uplink: intPtr(38000)}, &Carrier{Band: 38, Duplex: DuplexTDD, Downlink: floatPtr(2595), Uplink: floatPtr(2595)}},
+		{signalEARFCN{Downlink: intPtr(10000)}, &Carrier{Band: 32, Duplex: DuplexSDL, Downlink: floatPtr(1460)}},
+		{signalEARFCN{Downlink: intPtr(66886), Uplink: intPtr(132322)}, &Carrier{Band: 66, Duplex: DuplexFDD, Downlink: floatPtr(2155), Uplink: floatPtr(1745)}},
+		{signalEARFCN{}, nil},
+	}
+
+	for _, tt := range tests {
+		carrier, err := tt.earfcn.Carrier()
+		assert.Nil(t, err, "error deriving carrier: %q", err)
+		assert.Equal(t, tt.expected, carrier)
+	}
+}
+
+func TestLTECarrierErrors(t *testing.T) {
+	_, err := signalEARFCN{Downlink: intPtr(99999)}.Carrier()
+	assert.True(t, errors.Is(err, ErrUnknownChannel))
+
+	carrier, err := signalEARFCN{Downlink: intPtr(3025), Uplink: intPtr(19300)}.Carrier()
+	assert.EqualError(t, err, "uplink EARFCN 19300 is not in band 7 of downlink EARFCN 3025")
+	assert.Equal(t, 7, carrier.Band)
+	assert.Nil(t, carrier.Uplink)
+}
+
+func TestNRFrequency(t *testing.T) {
+	tests := []struct {
+		arfcn    int
+		expected float64
+	}{
+		{0, 0},
+		{158200, 791},
+		{428000, 2140},
+		{599999, 2999.995},
+		{600000, 3000},
+		{627264, 3408.96},
+		{2016667, 24250.08},
+		{2079167, 28000.08},
+	}
+
+	for _, tt := range tests {
+		f, err := NRFrequency(tt.arfcn)
+		assert.Nil(t, err, "error converting %d: %q", tt.arfcn, err)
+		assert.Equal(t, tt.expected, f, "NR-ARFCN %d", tt.arfcn)
+	}
+
+	_, err := NRFrequency(3279166)
+	assert.True(t, errors.Is(err, ErrUnknownChannel))
+}
+
+func TestNRBands(t *testing.T) {
+	assert.Equal(t, []int{78, 77}, NRBands(627264))
+	assert.Equal(t, []int{77}, NRBands(660000))
+	assert.Equal(t, []int{1, 66}, NRBands(428000))
+	assert.Equal(t, []int{7, 41}, NRBands(530000))
+	assert.Equal(t, []int{}, NRBands(100))
+
+	carrier, err := signalNRARFCN{Downlink: intPtr(530000)}.Carrier()
+	assert.Nil(t, err, "error deriving carrier: %q", err)
+	assert.Equal(t, &NRCarrier{Bands: []int{7, 41}, Duplex: DuplexFDD, Downlink: floatPtr(2650)}, carrier)
+
+	_, err = signalNRARFCN{Downlink: intPtr(100)}.Carrier()
+	assert.True(t, errors.Is(err, ErrUnknownChannel))
+}
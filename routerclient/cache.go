@@ -0,0 +1,164 @@
+package routerclient
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// cachedSession is the router session saved by the client with session cache enabled
+type cachedSession struct {
+	Cookies    map[string]string `json:"cookies"`
+	Tokens     []string          `json:"tokens"`
+	RSAN       string            `json:"rsan,omitempty"`
+	RSAE       string            `json:"rsae,omitempty"`
+	RSAPadding int               `json:"rsa_padding"`
+	SavedAt    time.Time         `json:"saved_at"`
+}
+
+// sessionCache holds sessions of all routers and users, keyed with sessionCacheKey
+type sessionCache map[string]cachedSession
+
+func (c *RouterClient) sessionCacheKey() string {
+	return c.username + "@" + c.routerURL
+}
+
+func readSessionCache(path string) (sessionCache, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sessionCache{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cache := sessionCache{}
+	err = json.Unmarshal(data, &cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// writeSessionCache replaces the cache file atomically, keeping it readable only by its owner
+func writeSessionCache(path string, cache sessionCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = f.Chmod(0600)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// restoreSession loads the cached session and reports whether router still considers it logged in.
+// Caller must hold sessionMu for writing.
+func (c *RouterClient) restoreSession(ctx context.Context) bool {
+	cache, err := readSessionCache(c.sessionCache)
+	if err != nil {
+		c.logf("reading session cache failed: %v", err)
+		return false
+	}
+
+	session, ok := cache[c.sessionCacheKey()]
+	if !ok {
+		return false
+	}
+
+	u, err := url.Parse(c.routerURL)
+	if err != nil {
+		return false
+	}
+
+	cookies := make([]*http.Cookie, 0, len(session.Cookies))
+	for name, value := range session.Cookies {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: value, Path: "/"})
+	}
+	c.client.Jar.SetCookies(u, cookies)
+
+	state, err := c.loginState(ctx)
+	if err != nil || !state.LoggedIn() {
+		c.logf("cached session is no longer valid")
+		return false
+	}
+
+	var publicKey *rsa.PublicKey
+	if session.RSAN != "" {
+		publicKey, _ = parsePublicKey(session.RSAN, session.RSAE)
+	}
+
+	c.mu.Lock()
+	c.tokens = append([]string(nil), session.Tokens...)
+	c.publicKey = publicKey
+	c.rsaPadding = session.RSAPadding
+	c.loggedIn = true
+	c.generation++
+	c.mu.Unlock()
+
+	c.logf("reusing cached session saved at %s", session.SavedAt.Format(time.RFC3339))
+	return true
+}
+
+// saveSession stores cookies, verification tokens and router public key in the session cache
+func (c *RouterClient) saveSession() error {
+	u, err := url.Parse(c.routerURL)
+	if err != nil {
+		return err
+	}
+
+	session := cachedSession{
+		Cookies: map[string]string{},
+		SavedAt: time.Now(),
+	}
+	for _, cookie := range c.client.Jar.Cookies(u) {
+		session.Cookies[cookie.Name] = cookie.Value
+	}
+
+	c.mu.Lock()
+	session.Tokens = append([]string(nil), c.tokens...)
+	if c.publicKey != nil {
+		session.RSAN = c.publicKey.N.Text(16)
+		session.RSAE = strconv.FormatInt(int64(c.publicKey.E), 16)
+	}
+	session.RSAPadding = c.rsaPadding
+	c.mu.Unlock()
+
+	cache, err := readSessionCache(c.sessionCache)
+	if err != nil {
+		c.logf("replacing unreadable session cache: %v", err)
+		cache = sessionCache{}
+	}
+
+	cache[c.sessionCacheKey()] = session
+	return writeSessionCache(c.sessionCache, cache)
+}
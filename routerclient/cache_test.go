@@ -0,0 +1,146 @@
+package routerclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionIsReusedFromCache(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "cache", "session.json")
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+	assert.Equal(t, 0, router.logouts, "session should be kept when cached")
+
+	info, err := os.Stat(cachePath)
+	assert.Nil(t, err, "session cache should be written: %q", err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	client, err = NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, 1, router.logins, "cached session should be reused")
+
+	_, err = client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+	assert.Equal(t, []string{"35ae2067cf278b183daab21a32d133e5"}, client.tokens)
+}
+
+func TestStaleCachedSessionIsReplaced(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "session.json")
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+
+	router.expire()
+
+	client, err = NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, 2, router.logins, "stale session should be replaced with a new one")
+
+	_, err = client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+}
+
+func TestSessionCacheIsKeptPerUser(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "session.json")
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	other, err := NewRouterClient(ts.URL, "other", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+	assert.False(t, other.restoreSession(context.Background()), "session of another user should not be reused")
+
+	cache, err := readSessionCache(cachePath)
+	assert.Nil(t, err, "error reading session cache: %q", err)
+	assert.Contains(t, cache, "admin@"+ts.URL)
+}
+
+func TestCorruptedSessionCacheIsIgnored(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "session.json")
+	err := ioutil.WriteFile(cachePath, []byte("not json"), 0600)
+	assert.Nil(t, err, "error writing file: %q", err)
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	_, err = readSessionCache(cachePath)
+	assert.Nil(t, err, "session cache should be rewritten: %q", err)
+}
+
+func TestPublicKeyIsCached(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err, "error generating key: %q", err)
+
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "session.json")
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	client.setPublicKey(&key.PublicKey)
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+
+	client, err = NewRouterClient(ts.URL, "admin", "pass", WithSessionCache(cachePath))
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, &key.PublicKey, client.publicKey)
+	assert.Equal(t, 1, client.rsaPadding)
+}
+
+func TestEmptySessionCachePathIsRejected(t *testing.T) {
+	_, err := NewRouterClient("http://localhost", "user", "pass", WithSessionCache(""))
+
+	assert.EqualError(t, err, "session cache path cannot be empty")
+}
@@ -0,0 +1,115 @@
+package routerclient
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+)
+
+// DeviceInfo is the information router reports about itself
+type DeviceInfo struct {
+	DeviceName      string `xml:"DeviceName" json:"DeviceName"`
+	SerialNumber    string `xml:"SerialNumber" json:"SerialNumber"`
+	IMEI            string `xml:"Imei" json:"IMEI"`
+	IMEISV          string `xml:"ImeiSvn" json:"IMEISV"`
+	IMSI            string `xml:"Imsi" json:"IMSI"`
+	ICCID           string `xml:"Iccid" json:"ICCID"`
+	MSISDN          string `xml:"Msisdn" json:"MSISDN"`
+	HardwareVersion string `xml:"HardwareVersion" json:"HardwareVersion"`
+	SoftwareVersion string `xml:"SoftwareVersion" json:"SoftwareVersion"`
+	WebUIVersion    string `xml:"WebUIVersion" json:"WebUIVersion"`
+	MACAddress1     string `xml:"MacAddress1" json:"MACAddress1"`
+	MACAddress2     string `xml:"MacAddress2" json:"MACAddress2"`
+	WANIPAddress    string `xml:"WanIPAddress" json:"WANIPAddress"`
+	WANIPv6Address  string `xml:"WanIPv6Address" json:"WANIPv6Address"`
+	ProductFamily   string `xml:"ProductFamily" json:"ProductFamily"`
+	Classify        string `xml:"Classify" json:"Classify"`
+	SupportedModes  string `xml:"supportmode" json:"SupportedModes"`
+	WorkMode        string `xml:"workmode" json:"WorkMode"`
+	MCCMNC          string `xml:"Mccmnc" json:"MCCMNC"`
+	// Uptime is the time since router was started, in seconds
+	Uptime *int `xml:"-" json:"Uptime"`
+
+	// Warnings lists values router reported in unexpected format
+	Warnings []string `xml:"-" json:"Warnings,omitempty"`
+}
+
+// Masked returns copy of device info with identifiers of the device and the SIM card masked,
+// only their last 4 characters are kept
+func (d DeviceInfo) Masked() DeviceInfo {
+	for _, v := range []*string{&d.SerialNumber, &d.IMEI, &d.IMEISV, &d.IMSI, &d.ICCID, &d.MSISDN} {
+		*v = mask(*v)
+	}
+	return d
+}
+
+func mask(v string) string {
+	const visible = 4
+	if len(v) <= visible {
+		return strings.Repeat("*", len(v))
+	}
+	return strings.Repeat("*", len(v)-visible) + v[len(v)-visible:]
+}
+
+// BasicDeviceInfo is the information router reports about itself before logging in
+type BasicDeviceInfo struct {
+	DeviceName      string `xml:"devicename" json:"DeviceName"`
+	ProductFamily   string `xml:"productfamily" json:"ProductFamily"`
+	Classify        string `xml:"classify" json:"Classify"`
+	SoftwareVersion string `xml:"SoftwareVersion" json:"SoftwareVersion"`
+	WebUIVersion    string `xml:"WebUIVersion" json:"WebUIVersion"`
+}
+
+// GetDeviceInfo connects to router and fetches device information
+func (c *RouterClient) GetDeviceInfo() (DeviceInfo, error) {
+	return c.GetDeviceInfoContext(context.Background())
+}
+
+// GetDeviceInfoContext is like GetDeviceInfo, but the request is bound to ctx
+func (c *RouterClient) GetDeviceInfoContext(ctx context.Context) (DeviceInfo, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", deviceInformationURL, "", nil)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+
+	return parseDeviceInfo(responseData)
+}
+
+func parseDeviceInfo(responseData []byte) (DeviceInfo, error) {
+	v := struct {
+		DeviceInfo
+		Uptime string `xml:"uptime"`
+	}{}
+	err := xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+
+	p := &valueParser{}
+	info := v.DeviceInfo
+	info.Uptime = p.integer("uptime", v.Uptime)
+	info.Warnings = p.warnings
+
+	return info, nil
+}
+
+// GetBasicDeviceInfo connects to router and fetches basic device information
+func (c *RouterClient) GetBasicDeviceInfo() (BasicDeviceInfo, error) {
+	return c.GetBasicDeviceInfoContext(context.Background())
+}
+
+// GetBasicDeviceInfoContext is like GetBasicDeviceInfo, but the request is bound to ctx
+func (c *RouterClient) GetBasicDeviceInfoContext(ctx context.Context) (BasicDeviceInfo, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", basicInformationURL, "", nil)
+	if err != nil {
+		return BasicDeviceInfo{}, err
+	}
+
+	info := BasicDeviceInfo{}
+	err = xml.Unmarshal(responseData, &info)
+	if err != nil {
+		return BasicDeviceInfo{}, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,99 @@
+package routerclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const deviceInformationResponse = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<DeviceName>B618s-22d</DeviceName>\n<SerialNumber>G4Q7S18A12345678</SerialNumber>\n<Imei>861234567890123</Imei>\n<Imsi>260031234567890</Imsi>\n<Iccid>8948031234567890123</Iccid>\n<Msisdn></Msisdn>\n<HardwareVersion>WL1B610FM</HardwareVersion>\n<SoftwareVersion>11.0.2.1(H183SP1C983)</SoftwareVersion>\n<WebUIVersion>WEBUI 11.0.2.1(W1SP7C983)</WebUIVersion>\n<MacAddress1>E4:A7:C5:11:22:33</MacAddress1>\n<MacAddress2></MacAddress2>\n<WanIPAddress>10.123.45.67</WanIPAddress>\n<wan_dns_address>10.1.1.1,10.1.1.2</wan_dns_address>\n<WanIPv6Address></WanIPv6Address>\n<wan_ipv6_dns_address></wan_ipv6_dns_address>\n<ProductFamily>LTE</ProductFamily>\n<Classify>cpe</Classify>\n<supportmode>LTE|WCDMA|GSM</supportmode>\n<workmode>LTE</workmode>\n<submask>255.255.255.255</submask>\n<Mccmnc>26003</Mccmnc>\n<uptime>86461</uptime>\n<ImeiSvn>12</ImeiSvn>\n</response>\n"
+
+func TestCanGetDeviceInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/device/information", r.URL.RequestURI())
+		fmt.Fprint(w, deviceInformationResponse)
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	info, err := client.GetDeviceInfo()
+
+	assert.Nil(t, err, "error getting device info: %q", err)
+	assert.Equal(t, DeviceInfo{
+		DeviceName:      "B618s-22d",
+		SerialNumber:    "G4Q7S18A12345678",
+		IMEI:            "861234567890123",
+		IMEISV:          "12",
+		IMSI:            "260031234567890",
+		ICCID:           "8948031234567890123",
+		HardwareVersion: "WL1B610FM",
+		SoftwareVersion: "11.0.2.1(H183SP1C983)",
+		WebUIVersion:    "WEBUI 11.0.2.1(W1SP7C983)",
+		MACAddress1:     "E4:A7:C5:11:22:33",
+		WANIPAddress:    "10.123.45.67",
+		ProductFamily:   "LTE",
+		Classify:        "cpe",
+		SupportedModes:  "LTE|WCDMA|GSM",
+		WorkMode:        "LTE",
+		MCCMNC:          "26003",
+		Uptime:          intPtr(86461),
+	}, info)
+}
+
+func TestDeviceInfoWithoutUptime(t *testing.T) {
+	info, err := parseDeviceInfo([]byte("<response><DeviceName>B618s-22d</DeviceName><uptime>n/a</uptime></response>"))
+
+	assert.Nil(t, err, "error parsing device info: %q", err)
+	assert.Equal(t, "B618s-22d", info.DeviceName)
+	assert.Nil(t, info.Uptime)
+	assert.Equal(t, []string{"uptime: unexpected value \"n/a\""}, info.Warnings)
+
+	info, err = parseDeviceInfo([]byte("<response><DeviceName>B618s-22d</DeviceName></response>"))
+
+	assert.Nil(t, err, "error parsing device info: %q", err)
+	assert.Nil(t, info.Uptime)
+	assert.Empty(t, info.Warnings, "missing uptime should not be reported as a warning")
+}
+
+func TestDeviceInfoCanBeMasked(t *testing.T) {
+	info, err := parseDeviceInfo([]byte(deviceInformationResponse))
+	assert.Nil(t, err, "error parsing device info: %q", err)
+
+	masked := info.Masked()
+
+	assert.Equal(t, "************5678", masked.SerialNumber)
+	assert.Equal(t, "***********0123", masked.IMEI)
+	assert.Equal(t, "**", masked.IMEISV)
+	assert.Equal(t, "***********7890", masked.IMSI)
+	assert.Equal(t, "***************0123", masked.ICCID)
+	assert.Equal(t, "", masked.MSISDN)
+	assert.Equal(t, info.DeviceName, masked.DeviceName)
+	assert.Equal(t, info.Uptime, masked.Uptime)
+	assert.Equal(t, "861234567890123", info.IMEI, "original should not be changed")
+}
+
+func TestCanGetBasicDeviceInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/device/basic_information", r.URL.RequestURI())
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<productfamily>LTE</productfamily>\n<classify>cpe</classify>\n<multimode>0</multimode>\n<restore_default_status>0</restore_default_status>\n<sim_save_pin_enable>0</sim_save_pin_enable>\n<devicename>B618s-22d</devicename>\n<SoftwareVersion>11.0.2.1(H183SP1C983)</SoftwareVersion>\n<WebUIVersion>WEBUI 11.0.2.1(W1SP7C983)</WebUIVersion>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewAnonymousRouterClient(ts.URL)
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	info, err := client.GetBasicDeviceInfo()
+
+	assert.Nil(t, err, "error getting basic device info: %q", err)
+	assert.Equal(t, BasicDeviceInfo{
+		DeviceName:      "B618s-22d",
+		ProductFamily:   "LTE",
+		Classify:        "cpe",
+		SoftwareVersion: "11.0.2.1(H183SP1C983)",
+		WebUIVersion:    "WEBUI 11.0.2.1(W1SP7C983)",
+	}, info)
+}
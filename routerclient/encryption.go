@@ -0,0 +1,119 @@
+package routerclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// encryptedContentType marks requests with RSA encrypted body, the same way web interface does
+const encryptedContentType = formContentType + ";enc"
+
+// RSA padding types reported by router in the login state
+const (
+	rsaPaddingPKCS1 = 0
+	rsaPaddingOAEP  = 1
+)
+
+// ErrNoPublicKey is returned when encrypted request is sent before router handed out its public key at login
+var ErrNoPublicKey = errors.New("router public key not available, login first")
+
+func parsePublicKey(rsan string, rsae string) (*rsa.PublicKey, error) {
+	n, ok := new(big.Int).SetString(rsan, 16)
+	if !ok {
+		return nil, errors.New("invalid router public key modulus")
+	}
+
+	e, err := strconv.ParseInt(rsae, 16, 32)
+	if err != nil {
+		return nil, errors.New("invalid router public key exponent")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e)}, nil
+}
+
+func (c *RouterClient) setPublicKey(publicKey *rsa.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publicKey = publicKey
+}
+
+func (c *RouterClient) setRSAPadding(padding int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rsaPadding = padding
+}
+
+// encrypt encrypts body the way web interface does: base64 encoded body is split into chunks
+// fitting the key size, every chunk is encrypted separately and hex encoded results are concatenated
+func (c *RouterClient) encrypt(body []byte) ([]byte, error) {
+	c.mu.Lock()
+	publicKey, padding := c.publicKey, c.rsaPadding
+	c.mu.Unlock()
+
+	if publicKey == nil {
+		return nil, ErrNoPublicKey
+	}
+
+	encrypt := func(chunk []byte) ([]byte, error) {
+		return rsa.EncryptPKCS1v15(rand.Reader, publicKey, chunk)
+	}
+	chunkSize := publicKey.Size() - 11
+	if padding == rsaPaddingOAEP {
+		encrypt = func(chunk []byte) ([]byte, error) {
+			return rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, chunk, nil)
+		}
+		chunkSize = publicKey.Size() - 2*sha1.Size - 2
+	}
+
+	if chunkSize <= 0 {
+		return nil, errors.New("router public key is too short")
+	}
+
+	plain := []byte(base64.StdEncoding.EncodeToString(body))
+	encrypted := make([]byte, 0, (len(plain)/chunkSize+1)*publicKey.Size()*2)
+	for start := 0; start < len(plain); start += chunkSize {
+		end := start + chunkSize
+		if end > len(plain) {
+			end = len(plain)
+		}
+
+		chunk, err := encrypt(plain[start:end])
+		if err != nil {
+			return nil, err
+		}
+		encrypted = append(encrypted, hex.EncodeToString(chunk)...)
+	}
+
+	return encrypted, nil
+}
+
+// PostEncrypted sends body to API endpoint encrypted with the router public key and returns the raw XML response,
+// as expected by endpoints accepting sensitive data such as passwords and PIN codes.
+// The key is handed out at login, so ErrNoPublicKey is returned before logging in.
+func (c *RouterClient) PostEncrypted(path string, body []byte) ([]byte, error) {
+	return c.PostEncryptedContext(context.Background(), path, body)
+}
+
+// PostEncryptedContext is like PostEncrypted, but the request is bound to ctx
+func (c *RouterClient) PostEncryptedContext(ctx context.Context, path string, body []byte) ([]byte, error) {
+	if err := checkAPIPath(path); err != nil {
+		return nil, err
+	}
+
+	return c.withRelogin(ctx, func() ([]byte, error) {
+		// key is replaced on every login, so the body is encrypted again for the retried request
+		encrypted, err := c.encrypt(body)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.doShared(ctx, "POST", path, encryptedContentType, encrypted)
+	})
+}
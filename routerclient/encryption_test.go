@@ -0,0 +1,99 @@
+package routerclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decryptBody reverses encryption done by the client, returning decrypted body
+func decryptBody(t *testing.T, key *rsa.PrivateKey, padding int, body []byte) string {
+	encrypted, err := hex.DecodeString(string(body))
+	assert.Nil(t, err, "encrypted body should be hex encoded: %q", err)
+
+	var plain []byte
+	for len(encrypted) > 0 {
+		var chunk []byte
+		if padding == rsaPaddingOAEP {
+			chunk, err = rsa.DecryptOAEP(sha1.New(), nil, key, encrypted[:key.Size()], nil)
+		} else {
+			chunk, err = rsa.DecryptPKCS1v15(nil, key, encrypted[:key.Size()])
+		}
+		assert.Nil(t, err, "error decrypting chunk: %q", err)
+		plain = append(plain, chunk...)
+		encrypted = encrypted[key.Size():]
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(plain))
+	assert.Nil(t, err, "decrypted body should be base64 encoded: %q", err)
+	return string(decoded)
+}
+
+func TestCanParsePublicKey(t *testing.T) {
+	key, err := parsePublicKey("94be3e6833721570", "010001")
+
+	assert.Nil(t, err, "error parsing public key: %q", err)
+	assert.Equal(t, 65537, key.E)
+	assert.Equal(t, "94be3e6833721570", key.N.Text(16))
+
+	_, err = parsePublicKey("xyz", "010001")
+	assert.NotNil(t, err)
+	_, err = parsePublicKey("94be3e6833721570", "")
+	assert.NotNil(t, err)
+}
+
+func TestEncryptRequiresPublicKey(t *testing.T) {
+	client, err := NewRouterClient("http://localhost", "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.PostEncryptedContext(context.Background(), "/api/user/password", []byte("<request></request>"))
+	assert.Equal(t, ErrNoPublicKey, err)
+
+	_, err = client.PostEncrypted("api/user/password", []byte("<request></request>"))
+	assert.EqualError(t, err, "path \"api/user/password\" must start with /")
+}
+
+func TestPostEncrypted(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err, "error generating key: %q", err)
+
+	// long enough to be split into several chunks
+	body := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><request><password>" + strings.Repeat("secret", 100) + "</password></request>"
+
+	for _, padding := range []int{rsaPaddingPKCS1, rsaPaddingOAEP} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/user/password", r.URL.RequestURI())
+			assert.Equal(t, "encrypt_transmit", r.Header.Get("encrypt_transmit"))
+			assert.Equal(t, "application/x-www-form-urlencoded; charset=UTF-8;enc", r.Header.Get("Content-Type"))
+
+			b, err := ioutil.ReadAll(r.Body)
+			assert.Nil(t, err, "error reading body: %q", err)
+			assert.Greater(t, len(b), 2*key.Size(), "body should be encrypted in several chunks")
+			assert.Equal(t, body, decryptBody(t, key, padding, b))
+
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		}))
+
+		client, err := NewRouterClient(ts.URL, "user", "pass")
+		assert.Nil(t, err, "error creating client: %q", err)
+		client.tokens = []string{"25ae2067cf278b183daab21a32d133e5"}
+		client.setPublicKey(&key.PublicKey)
+		client.setRSAPadding(padding)
+
+		_, err = client.PostEncryptedContext(context.Background(), "/api/user/password", []byte(body))
+		assert.Nil(t, err, "error sending encrypted request: %q", err)
+
+		ts.Close()
+	}
+}
@@ -0,0 +1,82 @@
+package routerclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors matching known router error codes, usable with errors.Is on errors returned by RouterClient
+var (
+	ErrWrongPassword   = errors.New("wrong username or password")
+	ErrTooManyAttempts = errors.New("too many login attempts")
+	ErrSessionTimeout  = errors.New("session timed out")
+	ErrNotSupported    = errors.New("operation not supported")
+	ErrBusy            = errors.New("router busy")
+)
+
+// ErrNoCredentials is returned by Login of client created with NewAnonymousRouterClient
+var ErrNoCredentials = errors.New("no credentials given, only requests available without login can be made")
+
+type knownError struct {
+	description string
+	sentinel    error
+}
+
+// knownErrors maps error codes reported by HiLink routers to their descriptions
+var knownErrors = map[int]knownError{
+	100002: {"operation not supported", ErrNotSupported},
+	100003: {"no rights, not logged in", ErrSessionTimeout},
+	100004: {"system busy", ErrBusy},
+	100005: {"format error", nil},
+	100006: {"parameter error", nil},
+	108001: {"wrong username", ErrWrongPassword},
+	108002: {"wrong password", ErrWrongPassword},
+	108003: {"already logged in", nil},
+	108006: {"wrong username or password", ErrWrongPassword},
+	108007: {"too many login attempts", ErrTooManyAttempts},
+	125001: {"wrong token", ErrSessionTimeout},
+	125002: {"wrong session", ErrSessionTimeout},
+	125003: {"wrong session token", ErrSessionTimeout},
+}
+
+// APIError is returned when router responds to a request with an error envelope
+type APIError struct {
+	Code     int
+	Message  string
+	Endpoint string
+	// Details holds other elements of the error response, reported by some endpoints
+	Details map[string]string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = knownErrors[e.Code].description
+	}
+	if msg == "" {
+		return fmt.Sprintf("router error %d (%s)", e.Code, e.Endpoint)
+	}
+	return fmt.Sprintf("router error %d (%s): %s", e.Code, e.Endpoint, msg)
+}
+
+// Is reports whether the error code belongs to the class represented by target sentinel error
+func (e *APIError) Is(target error) bool {
+	sentinel := knownErrors[e.Code].sentinel
+	return sentinel != nil && sentinel == target
+}
+
+// StatusError is returned when router responds with HTTP status other than 2xx
+type StatusError struct {
+	StatusCode int
+	Endpoint   string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("router returned HTTP status %d (%s)", e.StatusCode, e.Endpoint)
+}
+
+// Is reports service unavailable status as ErrBusy
+func (e *StatusError) Is(target error) bool {
+	return target == ErrBusy && e.StatusCode == http.StatusServiceUnavailable
+}
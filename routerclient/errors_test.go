@@ -0,0 +1,116 @@
+package routerclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func errorServer(code int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>%d</code>\n<message></message>\n</error>\n", code)
+	}))
+}
+
+func TestAPIErrorMatchesSentinel(t *testing.T) {
+	tests := []struct {
+		code     int
+		sentinel error
+	}{
+		{108006, ErrWrongPassword},
+		{108002, ErrWrongPassword},
+		{108007, ErrTooManyAttempts},
+		{125002, ErrSessionTimeout},
+		{100003, ErrSessionTimeout},
+		{100002, ErrNotSupported},
+		{100004, ErrBusy},
+	}
+
+	for _, tt := range tests {
+		err := error(&APIError{Code: tt.code, Endpoint: "/api/test"})
+		assert.True(t, errors.Is(err, tt.sentinel), "code %d should match %q", tt.code, tt.sentinel)
+		assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", err), tt.sentinel), "wrapped code %d should match %q", tt.code, tt.sentinel)
+	}
+
+	assert.False(t, errors.Is(&APIError{Code: 123456}, ErrBusy))
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	assert.EqualError(t, &APIError{Code: 108007, Endpoint: "/api/user/authentication_login"}, "router error 108007 (/api/user/authentication_login): too many login attempts")
+	assert.EqualError(t, &APIError{Code: 100004, Message: "busy", Endpoint: "/api/device/control"}, "router error 100004 (/api/device/control): busy")
+	assert.EqualError(t, &APIError{Code: 123456, Endpoint: "/api/device/signal"}, "router error 123456 (/api/device/signal)")
+}
+
+func TestChallengeLoginReportsWrongPassword(t *testing.T) {
+	ts := errorServer(108006)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	client.tokens = []string{"25ae2067cf278b183daab21a32d133e5"}
+	_, _, _, err = client.challengeLogin(context.Background(), "a8da1039e4ff4b71ba402591a7a324a7c400c068ed6c4697b670ec9002da816b")
+	assert.True(t, errors.Is(err, ErrWrongPassword), "wrong password error expected, got %q", err)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 108006, apiErr.Code)
+	assert.Equal(t, "/api/user/challenge_login", apiErr.Endpoint)
+}
+
+func TestAuthLoginReportsTooManyAttempts(t *testing.T) {
+	ts := errorServer(108007)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.authLogin(context.Background(), "6ae8b6a8273fa166c24fb11f63d2910db3a4602411023982578d03ea6caa1c54", 100, "6ae8b6a8273fa166c24fb11f63d2910db3a4602411023982578d03ea6caa1c54QscEJiy2Dbs0RJAsUN4rz4r8eZnfqbof", "fd4b1e6ad1b05db6ff288928fed3005ef4fdc9ade8be276220a8f41adcccda29")
+	assert.True(t, errors.Is(err, ErrTooManyAttempts), "too many attempts error expected, got %q", err)
+}
+
+func TestSignalStatsReportsBusyRouter(t *testing.T) {
+	ts := errorServer(100004)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.GetSignalStats()
+	assert.True(t, errors.Is(err, ErrBusy), "busy error expected, got %q", err)
+}
+
+func TestRebootReportsAPIError(t *testing.T) {
+	ts := errorServer(100002)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Reboot()
+	assert.True(t, errors.Is(err, ErrNotSupported), "not supported error expected, got %q", err)
+}
+
+func TestHTTPErrorStatusIsReported(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "<html><body>Service Unavailable</body></html>")
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.GetSignalStats()
+
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr), "unexpected error: %v", err)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.True(t, errors.Is(err, ErrBusy))
+	assert.EqualError(t, err, "router returned HTTP status 503 (/api/device/signal)")
+}
@@ -0,0 +1,111 @@
+package routerclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LockoutError is returned by Login when router refuses login attempts after too many failed ones.
+// Client does not try to log in again until Wait elapses, as every attempt would extend the lockout.
+type LockoutError struct {
+	Wait time.Duration
+	Err  error
+}
+
+func (e *LockoutError) Error() string {
+	if e.Wait <= 0 {
+		return "login locked by router"
+	}
+	return fmt.Sprintf("login locked by router, retry in %s", e.Wait)
+}
+
+func (e *LockoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes LockoutError match ErrTooManyAttempts
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// CredentialsError is returned by Login when router rejects the username or password
+type CredentialsError struct {
+	// RemainingAttempts is the number of attempts left before the lockout, -1 when router does not report it
+	RemainingAttempts int
+	Err               error
+}
+
+func (e *CredentialsError) Error() string {
+	if e.RemainingAttempts < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s, %d attempts remaining", e.Err, e.RemainingAttempts)
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// checkLockout refuses to log in while the lockout reported earlier is in force
+func (c *RouterClient) checkLockout() error {
+	c.mu.Lock()
+	lockedUntil := c.lockedUntil
+	c.mu.Unlock()
+
+	if wait := time.Until(lockedUntil); wait > 0 {
+		return &LockoutError{Wait: wait.Round(time.Second)}
+	}
+
+	return nil
+}
+
+func (c *RouterClient) lockFor(wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lockedUntil = time.Now().Add(wait)
+}
+
+// lockoutFromState returns LockoutError when login state reports that the login is locked
+func (c *RouterClient) lockoutFromState(state LoginState) error {
+	if !state.Locked && state.RemainingWait <= 0 {
+		return nil
+	}
+
+	c.lockFor(state.RemainingWait)
+	return &LockoutError{Wait: state.RemainingWait}
+}
+
+// loginFailure turns error returned when router rejected login into CredentialsError or LockoutError.
+// Values missing in the error response are taken from the login state.
+func (c *RouterClient) loginFailure(ctx context.Context, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !(errors.Is(err, ErrWrongPassword) || errors.Is(err, ErrTooManyAttempts)) {
+		return err
+	}
+
+	remainingAttempts := -1
+	if count, convErr := strconv.Atoi(apiErr.Details["count"]); convErr == nil {
+		remainingAttempts = count
+	}
+
+	var wait time.Duration
+	if minutes, convErr := strconv.Atoi(apiErr.Details["waittime"]); convErr == nil {
+		wait = time.Duration(minutes) * time.Minute
+	}
+
+	if !errors.Is(err, ErrTooManyAttempts) && remainingAttempts != 0 {
+		return &CredentialsError{RemainingAttempts: remainingAttempts, Err: err}
+	}
+
+	if wait <= 0 {
+		if state, stateErr := c.loginState(ctx); stateErr == nil {
+			wait = state.RemainingWait
+		}
+	}
+
+	c.lockFor(wait)
+	return &LockoutError{Wait: wait, Err: err}
+}
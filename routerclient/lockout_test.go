@@ -0,0 +1,140 @@
+package routerclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lockingRouter is a router reporting given login state and rejecting login with given error response
+func lockingRouter(t *testing.T, stateLogin string, loginError string) (*httptest.Server, *int) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.RequestURI() {
+		case "/":
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvzS7xTD7LGjRhXAtLCQRYWUKc5YdaRuzJJ</token></response>")
+		case "/api/user/state-login":
+			fmt.Fprint(w, stateLogin)
+		case "/api/user/challenge_login":
+			fmt.Fprint(w, loginError)
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	return ts, &requests
+}
+
+func TestLoginRefusedWhileLocked(t *testing.T) {
+	ts, requests := lockingRouter(t,
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><Username></Username><password_type>4</password_type><extern_password_type>1</extern_password_type><remain_wait_time>240</remain_wait_time><lockstatus>1</lockstatus></response>",
+		"")
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	var lockoutErr *LockoutError
+	assert.True(t, errors.As(err, &lockoutErr), "lockout error expected, got %q", err)
+	assert.Equal(t, 4*time.Minute, lockoutErr.Wait)
+	assert.True(t, errors.Is(err, ErrTooManyAttempts))
+	assert.Equal(t, "login locked by router, retry in 4m0s", err.Error())
+
+	requestsBefore := *requests
+	err = client.Login()
+	assert.True(t, errors.As(err, &lockoutErr), "lockout error expected, got %q", err)
+	assert.Greater(t, int64(lockoutErr.Wait), int64(3*time.Minute))
+	assert.Equal(t, requestsBefore, *requests, "client should not contact router while locked out")
+}
+
+func TestWrongPasswordReportsRemainingAttempts(t *testing.T) {
+	ts, _ := lockingRouter(t,
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><password_type>4</password_type><extern_password_type>1</extern_password_type><remain_wait_time>0</remain_wait_time><lockstatus>0</lockstatus></response>",
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?><error><code>108006</code><message></message><count>2</count></error>")
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	var credentialsErr *CredentialsError
+	assert.True(t, errors.As(err, &credentialsErr), "credentials error expected, got %q", err)
+	assert.Equal(t, 2, credentialsErr.RemainingAttempts)
+	assert.True(t, errors.Is(err, ErrWrongPassword))
+	assert.Equal(t, "router error 108006 (/api/user/challenge_login): wrong username or password, 2 attempts remaining", err.Error())
+
+	err = client.Login()
+	assert.True(t, errors.As(err, &credentialsErr), "client should be allowed to try again, got %q", err)
+}
+
+func TestWrongPasswordWithoutAttemptsLeftLocksLogin(t *testing.T) {
+	ts, _ := lockingRouter(t,
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><password_type>4</password_type><extern_password_type>1</extern_password_type><remain_wait_time>0</remain_wait_time><lockstatus>0</lockstatus></response>",
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?><error><code>108006</code><message></message><count>0</count><waittime>1</waittime></error>")
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	var lockoutErr *LockoutError
+	assert.True(t, errors.As(err, &lockoutErr), "lockout error expected, got %q", err)
+	assert.Equal(t, time.Minute, lockoutErr.Wait)
+	assert.True(t, errors.Is(err, ErrWrongPassword))
+}
+
+func TestTooManyAttemptsTakesWaitFromLoginState(t *testing.T) {
+	stateLogin := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><password_type>4</password_type><extern_password_type>1</extern_password_type><remain_wait_time>0</remain_wait_time><lockstatus>0</lockstatus></response>"
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/":
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvzS7xTD7LGjRhXAtLCQRYWUKc5YdaRuzJJ</token></response>")
+		case "/api/user/state-login":
+			calls++
+			if calls > 1 {
+				stateLogin = "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><password_type>4</password_type><extern_password_type>1</extern_password_type><remain_wait_time>300</remain_wait_time><lockstatus>1</lockstatus></response>"
+			}
+			fmt.Fprint(w, stateLogin)
+		case "/api/user/challenge_login":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><error><code>108007</code><message></message></error>")
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	var lockoutErr *LockoutError
+	assert.True(t, errors.As(err, &lockoutErr), "lockout error expected, got %q", err)
+	assert.Equal(t, 5*time.Minute, lockoutErr.Wait)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 108007, apiErr.Code)
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>108006</code>\n<message></message>\n<count> 3 </count>\n</error>\n")
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.GetSignalStats()
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, map[string]string{"count": "3"}, apiErr.Details)
+}
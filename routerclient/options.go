@@ -0,0 +1,181 @@
+package routerclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+)
+
+// Option configures RouterClient created with NewRouterClient
+type Option func(*RouterClient) error
+
+// Logger receives diagnostic messages from RouterClient, *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithTimeout sets the time limit for every single request sent to the router.
+// Zero disables the limit, leaving only the deadline of the context passed to the call.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *RouterClient) error {
+		if timeout < 0 {
+			return errors.New("timeout cannot be negative")
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithPasswordMode sets how the password is sent to router during login,
+// instead of detecting it from the login state reported by router
+func WithPasswordMode(mode PasswordMode) Option {
+	return func(c *RouterClient) error {
+		if mode < PasswordModeAuto || mode > PasswordModeBase64 {
+			return fmt.Errorf("invalid password mode %d", mode)
+		}
+		c.passwordMode = mode
+		return nil
+	}
+}
+
+// WithSessionCache makes Login reuse the session saved in the file at path when router still accepts it,
+// and Close save the session there instead of logging out.
+// Sessions are kept per router and username, the file is readable only by its owner.
+func WithSessionCache(path string) Option {
+	return func(c *RouterClient) error {
+		if path == "" {
+			return errors.New("session cache path cannot be empty")
+		}
+		c.sessionCache = path
+		return nil
+	}
+}
+
+// WithHTTPClient makes RouterClient send requests using a copy of httpClient.
+// Cookie jar is added to the copy when httpClient has none, as router session depends on cookies.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *RouterClient) error {
+		if httpClient == nil {
+			return errors.New("http client cannot be nil")
+		}
+
+		hc := *httpClient
+		if hc.Jar == nil {
+			jar, err := cookiejar.New(&cookiejar.Options{})
+			if err != nil {
+				return err
+			}
+			hc.Jar = jar
+		}
+
+		c.client = &hc
+		return nil
+	}
+}
+
+// WithTransport sets the transport used for sending requests, e.g. to use a proxy
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *RouterClient) error {
+		if transport == nil {
+			return errors.New("transport cannot be nil")
+		}
+		c.client.Transport = transport
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *RouterClient) error {
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithLogger makes RouterClient report requests it sends and sessions it renews to logger
+func WithLogger(logger Logger) Option {
+	return func(c *RouterClient) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithInsecureTLS disables verification of the router certificate.
+// Routers ship with self-signed certificates, so https connections fail without it or WithPinnedCert.
+func WithInsecureTLS() Option {
+	return func(c *RouterClient) error {
+		transport, err := c.httpTransport()
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithPinnedCert accepts only the router certificate with given SHA-256 fingerprint,
+// written as hex string, optionally with colons between bytes
+func WithPinnedCert(fingerprint string) Option {
+	return func(c *RouterClient) error {
+		pinned, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+		if err != nil {
+			return fmt.Errorf("invalid certificate fingerprint: %w", err)
+		}
+		if len(pinned) != sha256.Size {
+			return fmt.Errorf("invalid certificate fingerprint length %d, expected %d bytes", len(pinned), sha256.Size)
+		}
+
+		transport, err := c.httpTransport()
+		if err != nil {
+			return err
+		}
+
+		// certificate chain is not verified, pinned fingerprint replaces the verification
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		transport.TLSClientConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("router did not present a certificate")
+			}
+			got := sha256.Sum256(rawCerts[0])
+			if !bytes.Equal(got[:], pinned) {
+				return fmt.Errorf("router certificate fingerprint %x does not match pinned one", got)
+			}
+			return nil
+		}
+		return nil
+	}
+}
+
+// httpTransport replaces the client transport with its copy which TLS settings can be changed safely
+func (c *RouterClient) httpTransport() (*http.Transport, error) {
+	var transport *http.Transport
+	switch t := c.client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("TLS options cannot be used with transport of type %T", t)
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	c.client.Transport = transport
+	return transport, nil
+}
+
+func (c *RouterClient) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
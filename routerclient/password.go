@@ -0,0 +1,132 @@
+package routerclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// PasswordMode tells how the password is sent to router during login
+type PasswordMode int
+
+const (
+	// PasswordModeAuto picks the mode based on the login state reported by router
+	PasswordModeAuto PasswordMode = iota
+	// PasswordModeSCRAM uses SCRAM challenge login used by current B618 firmware
+	PasswordModeSCRAM
+	// PasswordModeSHA256 sends password hashed with SHA-256 (password type 4), used by older firmware
+	PasswordModeSHA256
+	// PasswordModeBase64 sends base64 encoded password (password type 0), used by the oldest firmware
+	PasswordModeBase64
+)
+
+// Password types reported by router in the login state
+const (
+	// passwordTypeUnknown is used when router does not report password type
+	passwordTypeUnknown     = -1
+	passwordTypeBase64      = 0
+	passwordTypeSHA256      = 4
+	externPasswordTypeSCRAM = 1
+)
+
+var passwordModeNames = map[PasswordMode]string{
+	PasswordModeAuto:   "auto",
+	PasswordModeSCRAM:  "scram",
+	PasswordModeSHA256: "sha256",
+	PasswordModeBase64: "base64",
+}
+
+func (m PasswordMode) String() string {
+	if name, ok := passwordModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("PasswordMode(%d)", int(m))
+}
+
+// ParsePasswordMode returns password mode with given name: auto, scram, sha256 or base64
+func ParsePasswordMode(name string) (PasswordMode, error) {
+	for mode, modeName := range passwordModeNames {
+		if strings.EqualFold(name, modeName) {
+			return mode, nil
+		}
+	}
+	return PasswordModeAuto, fmt.Errorf("unknown password mode %q", name)
+}
+
+// detectPasswordMode returns configured password mode, in auto mode choosing it based on the login state.
+// Firmware not reporting login state (nil state) is assumed to use SCRAM.
+func (c *RouterClient) detectPasswordMode(state *LoginState) PasswordMode {
+	if c.passwordMode != PasswordModeAuto {
+		return c.passwordMode
+	}
+
+	if state == nil {
+		return PasswordModeSCRAM
+	}
+
+	mode := PasswordModeSCRAM
+	switch {
+	case state.ExternPasswordType == externPasswordTypeSCRAM:
+	case state.PasswordType == passwordTypeSHA256:
+		mode = PasswordModeSHA256
+	case state.PasswordType == passwordTypeBase64:
+		mode = PasswordModeBase64
+	}
+
+	c.logf("using %s password mode", mode)
+	return mode
+}
+
+func sha256Base64(s string) string {
+	digest := sha256.Sum256([]byte(s))
+	return base64.StdEncoding.EncodeToString([]byte(hex.EncodeToString(digest[:])))
+}
+
+// calculateSHA256Password hashes password the way web interface does for password type 4:
+// base64(sha256(username + base64(sha256(password)) + token)), with sha256 digests hex encoded
+func calculateSHA256Password(username string, password string, token string) string {
+	return sha256Base64(username + sha256Base64(password) + token)
+}
+
+// passwordLogin logs in with the password sent to /api/user/login, used by firmware without SCRAM
+func (c *RouterClient) passwordLogin(ctx context.Context, mode PasswordMode) error {
+	token, err := c.nextToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	type PasswordLoginRequest struct {
+		XMLName      xml.Name `xml:"request"`
+		Username     string   `xml:"Username"`
+		Password     string   `xml:"Password"`
+		PasswordType int      `xml:"password_type"`
+	}
+
+	loginRequest := PasswordLoginRequest{
+		Username: c.username,
+	}
+
+	switch mode {
+	case PasswordModeSHA256:
+		loginRequest.Password = calculateSHA256Password(c.username, c.password, token)
+		loginRequest.PasswordType = passwordTypeSHA256
+	case PasswordModeBase64:
+		loginRequest.Password = base64.StdEncoding.EncodeToString([]byte(c.password))
+		loginRequest.PasswordType = passwordTypeBase64
+	default:
+		return fmt.Errorf("password mode %s cannot be used for password login", mode)
+	}
+
+	login, err := xml.Marshal(loginRequest)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.send(ctx, "POST", passwordLoginURL, formContentType, append([]byte(xml.Header), login...), token)
+
+	return err
+}
@@ -0,0 +1,126 @@
+package routerclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanCalculateSHA256Password(t *testing.T) {
+	res := calculateSHA256Password("admin", "MySecretPassword", "25ae2067cf278b183daab21a32d133e5")
+
+	assert.Equal(t, "YzhiM2EzMjBlZjVkODlkZThiMzM1N2ViNzI5Y2RhNzA3MDgyZGZmYzI5Y2FhNDg2YTdiMWFmODkxZWU2YWU3YQ==", res)
+}
+
+func TestParsePasswordMode(t *testing.T) {
+	for _, mode := range []PasswordMode{PasswordModeAuto, PasswordModeSCRAM, PasswordModeSHA256, PasswordModeBase64} {
+		parsed, err := ParsePasswordMode(mode.String())
+		assert.Nil(t, err, "error parsing password mode: %q", err)
+		assert.Equal(t, mode, parsed)
+	}
+
+	_, err := ParsePasswordMode("plain")
+	assert.EqualError(t, err, "unknown password mode \"plain\"")
+}
+
+func TestInvalidPasswordModeIsRejected(t *testing.T) {
+	_, err := NewRouterClient("http://localhost", "user", "pass", WithPasswordMode(PasswordMode(42)))
+
+	assert.EqualError(t, err, "invalid password mode 42")
+}
+
+// legacyRouter is a router with password login, reporting given password type in its login state
+func legacyRouter(t *testing.T, passwordType int, expectedBody string) (*httptest.Server, *int) {
+	logins := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/":
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvz25ae2067cf278b183daab21a32d133e5</token></response>")
+		case "/api/user/state-login":
+			fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><Username></Username><password_type>%d</password_type><firstlogin>1</firstlogin><remain_wait_time>0</remain_wait_time><lockstatus>0</lockstatus><accounts_number>1</accounts_number></response>", passwordType)
+		case "/api/user/login":
+			assert.Equal(t, "25ae2067cf278b183daab21a32d133e5", r.Header.Get(requestVerificationToken))
+			b, err := ioutil.ReadAll(r.Body)
+			assert.Nil(t, err, "error reading body: %q", err)
+			assert.Equal(t, expectedBody, string(b))
+			logins++
+			w.Header().Add("__RequestVerificationToken", "35ae2067cf278b183daab21a32d133e5")
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	return ts, &logins
+}
+
+func TestLoginDetectsSHA256Password(t *testing.T) {
+	ts, logins := legacyRouter(t, 4, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<request><Username>admin</Username><Password>YzhiM2EzMjBlZjVkODlkZThiMzM1N2ViNzI5Y2RhNzA3MDgyZGZmYzI5Y2FhNDg2YTdiMWFmODkxZWU2YWU3YQ==</Password><password_type>4</password_type></request>")
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "MySecretPassword")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, 1, *logins)
+	assert.Equal(t, []string{"35ae2067cf278b183daab21a32d133e5"}, client.tokens)
+}
+
+func TestLoginDetectsBase64Password(t *testing.T) {
+	ts, logins := legacyRouter(t, 0, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<request><Username>admin</Username><Password>TXlTZWNyZXRQYXNzd29yZA==</Password><password_type>0</password_type></request>")
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "MySecretPassword")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, 1, *logins)
+}
+
+func TestPasswordModeOverridesDetection(t *testing.T) {
+	ts, logins := legacyRouter(t, 0, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<request><Username>admin</Username><Password>YzhiM2EzMjBlZjVkODlkZThiMzM1N2ViNzI5Y2RhNzA3MDgyZGZmYzI5Y2FhNDg2YTdiMWFmODkxZWU2YWU3YQ==</Password><password_type>4</password_type></request>")
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "MySecretPassword", WithPasswordMode(PasswordModeSHA256))
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, 1, *logins)
+}
+
+func TestLoginUsesSCRAMWhenReported(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	state, err := client.LoginStateContext(context.Background())
+	assert.Nil(t, err, "error getting login state: %q", err)
+	assert.Equal(t, PasswordModeSCRAM, client.detectPasswordMode(&state))
+	assert.Equal(t, PasswordModeSCRAM, client.detectPasswordMode(nil))
+}
+
+func TestMissingPasswordTypeDefaultsToSCRAM(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>-1</State><Username></Username><firstlogin>1</firstlogin><remain_wait_time>0</remain_wait_time><lockstatus>0</lockstatus></response>")
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	state, err := client.LoginStateContext(context.Background())
+	assert.Nil(t, err, "error getting login state: %q", err)
+	assert.Equal(t, -1, state.PasswordType)
+	assert.Equal(t, PasswordModeSCRAM, client.detectPasswordMode(&state))
+}
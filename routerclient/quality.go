@@ -0,0 +1,215 @@
+package routerclient
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Grade is the assessment of a single signal metric
+type Grade int
+
+// Grades from the worst to the best, GradeUnknown is used when the metric was not reported
+const (
+	GradeUnknown Grade = iota
+	GradeNoSignal
+	GradePoor
+	GradeFair
+	GradeGood
+	GradeExcellent
+)
+
+var gradeNames = map[Grade]string{
+	GradeUnknown:   "unknown",
+	GradeNoSignal:  "no signal",
+	GradePoor:      "poor",
+	GradeFair:      "fair",
+	GradeGood:      "good",
+	GradeExcellent: "excellent",
+}
+
+func (g Grade) String() string {
+	if name, ok := gradeNames[g]; ok {
+		return name
+	}
+	return fmt.Sprintf("grade %d", int(g))
+}
+
+// MarshalJSON encodes the grade as its name
+func (g Grade) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.String())
+}
+
+// UnmarshalJSON decodes the grade from its name
+func (g *Grade) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	for grade, gradeName := range gradeNames {
+		if name == gradeName {
+			*g = grade
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown grade %q", name)
+}
+
+// Thresholds are the lowest values of a metric for each grade,
+// values below Poor are graded as no signal
+type Thresholds struct {
+	Excellent float64 `yaml:"excellent" json:"Excellent"`
+	Good      float64 `yaml:"good" json:"Good"`
+	Fair      float64 `yaml:"fair" json:"Fair"`
+	Poor      float64 `yaml:"poor" json:"Poor"`
+}
+
+// Grade assesses value, nil value is graded as unknown
+func (t Thresholds) Grade(v *float64) Grade {
+	switch {
+	case v == nil:
+		return GradeUnknown
+	case *v >= t.Excellent:
+		return GradeExcellent
+	case *v >= t.Good:
+		return GradeGood
+	case *v >= t.Fair:
+		return GradeFair
+	case *v >= t.Poor:
+		return GradePoor
+	default:
+		return GradeNoSignal
+	}
+}
+
+// score maps value linearly from 0 at Poor threshold to 100 at Excellent threshold
+func (t Thresholds) score(v float64) float64 {
+	if t.Excellent <= t.Poor {
+		return 0
+	}
+
+	s := (v - t.Poor) / (t.Excellent - t.Poor) * 100
+	switch {
+	case s < 0:
+		return 0
+	case s > 100:
+		return 100
+	default:
+		return s
+	}
+}
+
+// Validate checks that thresholds are in descending order
+func (t Thresholds) Validate() error {
+	if t.Excellent < t.Good || t.Good < t.Fair || t.Fair < t.Poor {
+		return fmt.Errorf("thresholds must be in order excellent >= good >= fair >= poor, got %v, %v, %v, %v",
+			t.Excellent, t.Good, t.Fair, t.Poor)
+	}
+	return nil
+}
+
+// QualityThresholds holds thresholds of graded metrics. Bars are the lowest RSRP values
+// for 1, 2, ... bars of the signal indicator.
+type QualityThresholds struct {
+	RSRP Thresholds `yaml:"rsrp" json:"RSRP"`
+	RSRQ Thresholds `yaml:"rsrq" json:"RSRQ"`
+	SINR Thresholds `yaml:"sinr" json:"SINR"`
+	RSSI Thresholds `yaml:"rssi" json:"RSSI"`
+	Bars []float64  `yaml:"bars" json:"Bars"`
+}
+
+// DefaultQualityThresholds are the commonly used LTE signal ranges, with 5 bars like router web UI
+var DefaultQualityThresholds = QualityThresholds{
+	RSRP: Thresholds{Excellent: -80, Good: -90, Fair: -100, Poor: -120},
+	RSRQ: Thresholds{Excellent: -10, Good: -15, Fair: -20, Poor: -30},
+	SINR: Thresholds{Excellent: 20, Good: 13, Fair: 0, Poor: -20},
+	RSSI: Thresholds{Excellent: -65, Good: -75, Fair: -85, Poor: -110},
+	Bars: []float64{-120, -110, -100, -90, -80},
+}
+
+// Validate checks thresholds of all metrics, returning the first problem found
+func (q QualityThresholds) Validate() error {
+	metrics := []struct {
+		name       string
+		thresholds Thresholds
+	}{
+		{"rsrp", q.RSRP},
+		{"rsrq", q.RSRQ},
+		{"sinr", q.SINR},
+		{"rssi", q.RSSI},
+	}
+
+	for _, m := range metrics {
+		if err := m.thresholds.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+	}
+
+	for i := 1; i < len(q.Bars); i++ {
+		if q.Bars[i] < q.Bars[i-1] {
+			return fmt.Errorf("bars: thresholds must be in ascending order, got %v", q.Bars)
+		}
+	}
+
+	return nil
+}
+
+// Quality is the assessment of signal
+type Quality struct {
+	RSRP Grade `json:"RSRP,omitempty"`
+	RSRQ Grade `json:"RSRQ,omitempty"`
+	SINR Grade `json:"SINR,omitempty"`
+	RSSI Grade `json:"RSSI,omitempty"`
+	// Score is 0-100 average of RSRP, RSRQ and SINR scores, of the ones reported
+	Score int `json:"Score"`
+	// Bars is the number of bars of the signal indicator, based on RSRP
+	Bars int `json:"Bars"`
+}
+
+// AssessQuality grades signal metrics with thresholds given. Metrics not reported
+// are graded as unknown and are not included in the score.
+func AssessQuality(s Signal, t QualityThresholds) Quality {
+	q := Quality{
+		RSRP: t.RSRP.Grade(s.RSRP),
+		RSRQ: t.RSRQ.Grade(s.RSRQ),
+		SINR: t.SINR.Grade(s.SINR),
+		RSSI: t.RSSI.Grade(s.RSSI),
+	}
+
+	scored := []struct {
+		value      *float64
+		thresholds Thresholds
+	}{
+		{s.RSRP, t.RSRP},
+		{s.RSRQ, t.RSRQ},
+		{s.SINR, t.SINR},
+	}
+
+	total, n := 0.0, 0
+	for _, m := range scored {
+		if m.value == nil {
+			continue
+		}
+		total += m.thresholds.score(*m.value)
+		n++
+	}
+	if n > 0 {
+		q.Score = int(total/float64(n) + 0.5)
+	}
+
+	if s.RSRP != nil {
+		for _, threshold := range t.Bars {
+			if *s.RSRP >= threshold {
+				q.Bars++
+			}
+		}
+	}
+
+	return q
+}
+
+// Quality grades signal metrics with DefaultQualityThresholds
+func (s Signal) Quality() Quality {
+	return AssessQuality(s, DefaultQualityThresholds)
+}
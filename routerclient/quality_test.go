@@ -0,0 +1,89 @@
+package routerclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholdsGrade(t *testing.T) {
+	thresholds := DefaultQualityThresholds.RSRP
+
+	assert.Equal(t, GradeExcellent, thresholds.Grade(floatPtr(-70)))
+	assert.Equal(t, GradeExcellent, thresholds.Grade(floatPtr(-80)))
+	assert.Equal(t, GradeGood, thresholds.Grade(floatPtr(-80.5)))
+	assert.Equal(t, GradeFair, thresholds.Grade(floatPtr(-100)))
+	assert.Equal(t, GradePoor, thresholds.Grade(floatPtr(-110)))
+	assert.Equal(t, GradeNoSignal, thresholds.Grade(floatPtr(-125)))
+	assert.Equal(t, GradeUnknown, thresholds.Grade(nil))
+}
+
+func TestCanAssessQuality(t *testing.T) {
+	signal := Signal{RSRP: floatPtr(-86), RSRQ: floatPtr(-14), SINR: floatPtr(10), RSSI: floatPtr(-61)}
+
+	assert.Equal(t, Quality{
+		RSRP:  GradeGood,
+		RSRQ:  GradeGood,
+		SINR:  GradeFair,
+		RSSI:  GradeExcellent,
+		Score: 80,
+		Bars:  4,
+	}, signal.Quality())
+}
+
+func TestQualityOfBoundarySignal(t *testing.T) {
+	assert.Equal(t, Quality{
+		RSRP: GradeExcellent, RSRQ: GradeExcellent, SINR: GradeExcellent, RSSI: GradeExcellent, Score: 100, Bars: 5,
+	}, Signal{RSRP: floatPtr(-44), RSRQ: floatPtr(-3), SINR: floatPtr(30), RSSI: floatPtr(-51)}.Quality())
+
+	assert.Equal(t, Quality{
+		RSRP: GradeNoSignal, RSRQ: GradeNoSignal, SINR: GradeNoSignal, RSSI: GradeNoSignal, Score: 0, Bars: 0,
+	}, Signal{RSRP: floatPtr(-140), RSRQ: floatPtr(-34), SINR: floatPtr(-23), RSSI: floatPtr(-113)}.Quality())
+}
+
+func TestMissingMetricsAreNotScored(t *testing.T) {
+	q := Signal{SINR: floatPtr(20)}.Quality()
+
+	assert.Equal(t, Quality{SINR: GradeExcellent, Score: 100}, q)
+	assert.Equal(t, Quality{}, Signal{}.Quality())
+}
+
+func TestCustomThresholds(t *testing.T) {
+	thresholds := DefaultQualityThresholds
+	thresholds.RSRP = Thresholds{Excellent: -90, Good: -100, Fair: -110, Poor: -130}
+	thresholds.Bars = []float64{-110, -90}
+
+	q := AssessQuality(Signal{RSRP: floatPtr(-95)}, thresholds)
+
+	assert.Equal(t, GradeGood, q.RSRP)
+	assert.Equal(t, 88, q.Score)
+	assert.Equal(t, 1, q.Bars)
+}
+
+func TestValidateQualityThresholds(t *testing.T) {
+	assert.Nil(t, DefaultQualityThresholds.Validate())
+
+	thresholds := DefaultQualityThresholds
+	thresholds.RSRQ.Poor = -5
+	assert.EqualError(t, thresholds.Validate(),
+		"rsrq: thresholds must be in order excellent >= good >= fair >= poor, got -10, -15, -20, -5")
+
+	thresholds = DefaultQualityThresholds
+	thresholds.Bars = []float64{-80, -90}
+	assert.EqualError(t, thresholds.Validate(), "bars: thresholds must be in ascending order, got [-80 -90]")
+}
+
+func TestQualityJSON(t *testing.T) {
+	data, err := json.Marshal(Quality{RSRP: GradeNoSignal, SINR: GradeExcellent, Score: 50, Bars: 0})
+	assert.Nil(t, err, "error marshalling: %q", err)
+	assert.Equal(t, `{"RSRP":"no signal","SINR":"excellent","Score":50,"Bars":0}`, string(data))
+
+	var decoded Quality
+	err = json.Unmarshal(data, &decoded)
+	assert.Nil(t, err, "error unmarshalling: %q", err)
+	assert.Equal(t, Quality{RSRP: GradeNoSignal, SINR: GradeExcellent, Score: 50}, decoded)
+
+	err = json.Unmarshal([]byte(`{"RSRP":"great"}`), &decoded)
+	assert.EqualError(t, err, `unknown grade "great"`)
+}
@@ -0,0 +1,44 @@
+package routerclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func checkAPIPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path %q must start with /", path)
+	}
+	return nil
+}
+
+// Get sends GET request to any API endpoint, e.g. /api/monitoring/status, and returns the raw XML response.
+// Session and verification tokens are handled like for other requests, router errors are returned as *APIError.
+func (c *RouterClient) Get(path string) ([]byte, error) {
+	return c.GetContext(context.Background(), path)
+}
+
+// GetContext is like Get, but the request is bound to ctx
+func (c *RouterClient) GetContext(ctx context.Context, path string) ([]byte, error) {
+	if err := checkAPIPath(path); err != nil {
+		return nil, err
+	}
+
+	return c.doWithRelogin(ctx, "GET", path, "", nil)
+}
+
+// Post sends XML body to any API endpoint and returns the raw XML response.
+// Session and verification tokens are handled like for other requests, router errors are returned as *APIError.
+func (c *RouterClient) Post(path string, body []byte) ([]byte, error) {
+	return c.PostContext(context.Background(), path, body)
+}
+
+// PostContext is like Post, but the request is bound to ctx
+func (c *RouterClient) PostContext(ctx context.Context, path string, body []byte) ([]byte, error) {
+	if err := checkAPIPath(path); err != nil {
+		return nil, err
+	}
+
+	return c.doWithRelogin(ctx, "POST", path, formContentType, body)
+}
@@ -0,0 +1,78 @@
+package routerclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReturnsRawResponse(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	router.expire()
+	response, err := client.Get("/api/device/signal")
+
+	assert.Nil(t, err, "error sending request: %q", err)
+	assert.Contains(t, string(response), "<rsrp>-86dBm</rsrp>")
+	assert.Equal(t, 2, router.logins, "client should log in again after session expired")
+}
+
+func TestPostSendsBodyWithToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/dialup/mobile-dataswitch", r.URL.Path)
+		assert.Equal(t, "token1", r.Header.Get(requestVerificationToken))
+		assert.Equal(t, formContentType, r.Header.Get("Content-Type"))
+		assert.Equal(t, "<request><dataswitch>1</dataswitch></request>", string(body))
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+	client.tokens = []string{"token1"}
+
+	response, err := client.Post("/api/dialup/mobile-dataswitch", []byte("<request><dataswitch>1</dataswitch></request>"))
+
+	assert.Nil(t, err, "error sending request: %q", err)
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n", string(response))
+}
+
+func TestRawRequestReturnsAPIError(t *testing.T) {
+	ts := errorServer(100002)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.Get("/api/unknown")
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr), "unexpected error: %v", err)
+	assert.Equal(t, "/api/unknown", apiErr.Endpoint)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+}
+
+func TestRawRequestPathMustBeAbsolute(t *testing.T) {
+	client, err := NewRouterClient("http://localhost", "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.Get("api/monitoring/status")
+	assert.EqualError(t, err, `path "api/monitoring/status" must start with /`)
+
+	_, err = client.Post("api/device/control", nil)
+	assert.EqualError(t, err, `path "api/device/control" must start with /`)
+}
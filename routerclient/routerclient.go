@@ -2,68 +2,79 @@ package routerclient
 
 import (
 	"bytes"
+	"context"
+	"crypto/rsa"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
-	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	tokenURL                 = "/api/webserver/token"
+	sesTokInfoURL            = "/api/webserver/SesTokInfo"
 	challengeLoginURL        = "/api/user/challenge_login"
 	authLoginURL             = "/api/user/authentication_login"
+	passwordLoginURL         = "/api/user/login"
+	logoutURL                = "/api/user/logout"
+	stateLoginURL            = "/api/user/state-login"
 	signalURL                = "/api/device/signal"
 	controlURL               = "/api/device/control"
+	deviceInformationURL     = "/api/device/information"
+	basicInformationURL      = "/api/device/basic_information"
+	statusURL                = "/api/monitoring/status"
+	trafficStatisticsURL     = "/api/monitoring/traffic-statistics"
+	monthStatisticsURL       = "/api/monitoring/month_statistics"
+	startDateURL             = "/api/monitoring/start_date"
+	clearTrafficURL          = "/api/monitoring/clear-traffic"
 	requestVerificationToken = "__requestverificationtoken"
+	// formContentType is the content type router web interface uses for API requests
+	formContentType = "application/x-www-form-urlencoded; charset=UTF-8"
 )
 
+// DefaultTimeout is the time limit for a single request sent to the router, unless configured otherwise with WithTimeout
+const DefaultTimeout = 30 * time.Second
+
 // RouterClient is a client used for connecting and managing router
 //
-// The preferred way of constructing client is calling NewRouterClient() method
+// The preferred way of constructing client is calling NewRouterClient() method.
+// RouterClient is safe for concurrent use by multiple goroutines.
 type RouterClient struct {
-	client                   *http.Client
-	routerURL                string
-	username                 string
-	password                 string
-	requestVerificationToken string
-}
-
-type signalBandwidth struct {
-	Upload   int
-	Download int
-}
-
-type signalEARFCN struct {
-	Uplink   int
-	Downlink int
-}
-
-type signalPower struct {
-	PUSCH int
-	PUCCH int
-	SRS   int
-	PRACH int
-}
-
-//Signal stores signal parameters
-type Signal struct {
-	RSRQ      int
-	RSRP      int
-	RSSI      int
-	SINR      int
-	Bandwidth signalBandwidth
-	Power     signalPower
-	EARFCN    signalEARFCN
+	client       *http.Client
+	routerURL    string
+	username     string
+	password     string
+	timeout      time.Duration
+	userAgent    string
+	logger       Logger
+	passwordMode PasswordMode
+	sessionCache string
+
+	// sessionMu is held for writing while logging in, requests needing the session hold it for reading
+	sessionMu sync.RWMutex
+
+	// mu guards the fields below
+	mu          sync.Mutex
+	tokens      []string
+	loggedIn    bool
+	generation  uint64
+	publicKey   *rsa.PublicKey
+	rsaPadding  int
+	lockedUntil time.Time
 }
 
 // NewRouterClient constructs new Routerclient object, validating provided arguments
 // It does not log in to router nor it creates the session
-func NewRouterClient(routerURL string, username string, password string) (*RouterClient, error) {
+//
+// Options are applied in the order they are passed
+func NewRouterClient(routerURL string, username string, password string, opts ...Option) (*RouterClient, error) {
 
 	if routerURL == "" {
 		return nil, errors.New("routerURL cannot be empty")
@@ -90,6 +101,25 @@ func NewRouterClient(routerURL string, username string, password string) (*Route
 		return nil, errors.New("password cannot be empty")
 	}
 
+	return newRouterClient(url, username, password, opts)
+}
+
+// NewAnonymousRouterClient constructs client without credentials, for the requests router serves
+// before logging in, such as GetBasicDeviceInfo. Login fails with ErrNoCredentials.
+func NewAnonymousRouterClient(routerURL string, opts ...Option) (*RouterClient, error) {
+	if routerURL == "" {
+		return nil, errors.New("routerURL cannot be empty")
+	}
+
+	url, err := url.Parse(routerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRouterClient(url, "", "", opts)
+}
+
+func newRouterClient(url *url.URL, username string, password string, opts []Option) (*RouterClient, error) {
 	jar, err := cookiejar.New(&cookiejar.Options{})
 	if err != nil {
 		return nil, err
@@ -104,135 +134,129 @@ func NewRouterClient(routerURL string, username string, password string) (*Route
 		client: &http.Client{
 			Jar: jar,
 		},
+		timeout: DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		if err := opt(routerClient); err != nil {
+			return nil, err
+		}
 	}
 
 	return routerClient, nil
 }
 
-func (c RouterClient) initSession() error {
-	_, err := c.client.Get(c.routerURL)
+func (c *RouterClient) initSession(ctx context.Context) error {
+	_, err := c.do(ctx, "GET", "/", "", nil)
 
-	if err != nil {
-		return err
+	return err
+}
+
+// do sends a single request to the router and returns the response body.
+// Verification token from the pool is attached to POST requests.
+func (c *RouterClient) do(ctx context.Context, method string, path string, contentType string, body []byte) ([]byte, error) {
+	token := ""
+	if method == "POST" {
+		var err error
+		token, err = c.nextToken(ctx)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return c.send(ctx, method, path, contentType, body, token)
 }
 
-func getdBValue(v string) int {
-	if v == "" || v[len(v)-2:] != "dB" {
-		return 0
+// send sends a single request with given verification token to the router and returns the response body.
+// New tokens are taken from the response headers, error envelopes returned by router are converted to *APIError.
+// Request is cancelled when ctx is done or the client timeout elapses.
+func (c *RouterClient) send(ctx context.Context, method string, path string, contentType string, body []byte, token string) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
 	}
-	val, _ := strconv.Atoi(v[0 : len(v)-2])
-	return val
-}
 
-func getdBMValue(v string) int {
-	if v == "" || v[len(v)-3:] != "dBm" {
-		return 0
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.routerURL+path, reqBody)
+	if err != nil {
+		return nil, err
 	}
-	val, _ := strconv.Atoi(v[0 : len(v)-3])
-	return val
-}
 
-func getMHzValue(v string) int {
-	if v == "" || v[len(v)-3:] != "MHz" {
-		return 0
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
 	}
-	val, _ := strconv.Atoi(v[0 : len(v)-3])
-	return val
-}
 
-func getEARFCN(v string) signalEARFCN {
-	e := signalEARFCN{}
-	for _, f := range strings.Fields(v) {
-		if len(f) < 4 {
-			continue
-		}
-		val, err := strconv.Atoi(f[3:])
-		if err != nil {
-			continue
-		}
+	if contentType != "" {
+		req.Header.Add("Content-Type", contentType)
+	}
 
-		switch f[0:3] {
-		case "UL:":
-			e.Uplink = val
-		case "DL:":
-			e.Downlink = val
-		}
+	if token != "" {
+		req.Header.Add(requestVerificationToken, token)
 	}
-	return e
-}
 
-func getSignalPower(v string) signalPower {
-	p := signalPower{}
-	for _, f := range strings.Fields(v) {
-		e := strings.FieldsFunc(f, func(c rune) bool { return c == ':' })
-		if len(f) < 2 {
-			continue
-		}
-		switch e[0] {
-		case "PPusch":
-			p.PUSCH = getdBMValue(e[1])
-		case "PPucch":
-			p.PUCCH = getdBMValue(e[1])
-		case "PSrs":
-			p.SRS = getdBMValue(e[1])
-		case "PPrach":
-			p.PRACH = getdBMValue(e[1])
-		}
+	if contentType == encryptedContentType {
+		req.Header.Add("encrypt_transmit", "encrypt_transmit")
 	}
-	return p
-}
 
-// GetSignalStats connects to router and fetches current signal stats
-func (c *RouterClient) GetSignalStats() (Signal, error) {
-	resp, err := c.client.Get(c.routerURL + signalURL)
+	c.logf("%s %s", method, path)
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return Signal{}, err
+		c.logf("%s %s failed: %v", method, path, err)
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	responseData, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return Signal{}, err
-	}
-
-	type SignalResponse struct {
-		RSRQ              string `xml:"rsrq"`
-		RSRP              string `xml:"rsrp"`
-		RSSI              string `xml:"rssi"`
-		SINR              string `xml:"sinr"`
-		UploadBandwidth   string `xml:"ulbandwidth"`
-		DownloadBandwidth string `xml:"dlbandwidth"`
-		TXPower           string `xml:"txpower"`
-		EARFCN            string `xml:"earfcn"`
+		return nil, err
 	}
 
-	v := SignalResponse{}
+	c.updateVerificationTokenFromHeaders(resp)
 
-	err = xml.Unmarshal(responseData, &v)
-	if err != nil {
-		return Signal{}, err
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		c.logf("%s %s returned status %d", method, path, resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Endpoint: path}
 	}
-	signal := Signal{
-		RSRQ: getdBValue(v.RSRQ),
-		RSRP: getdBMValue(v.RSRP),
-		RSSI: getdBMValue(v.RSSI),
-		SINR: getdBValue(v.SINR),
-		Bandwidth: signalBandwidth{
-			Upload:   getMHzValue(v.UploadBandwidth),
-			Download: getMHzValue(v.DownloadBandwidth),
-		},
-		EARFCN: getEARFCN(v.EARFCN),
-		Power:  getSignalPower(v.TXPower),
+
+	type ErrorResponse struct {
+		XMLName xml.Name `xml:"error"`
+		Code    int      `xml:"code"`
+		Message string   `xml:"message"`
+		Details []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
 	}
 
-	return signal, nil
+	e := ErrorResponse{}
+	if xml.Unmarshal(responseData, &e) == nil {
+		c.logf("%s %s returned error %d", method, path, e.Code)
+		apiErr := &APIError{Code: e.Code, Message: e.Message, Endpoint: path}
+		for _, d := range e.Details {
+			if apiErr.Details == nil {
+				apiErr.Details = map[string]string{}
+			}
+			apiErr.Details[d.XMLName.Local] = strings.TrimSpace(d.Value)
+		}
+		return nil, apiErr
+	}
 
+	return responseData, nil
 }
 
 // Reboot reboots the router ;)
-func (c RouterClient) Reboot() error {
+// Rebooting drops all router sessions, so the client is logged out afterwards
+func (c *RouterClient) Reboot() error {
+	return c.RebootContext(context.Background())
+}
+
+// RebootContext is like Reboot, but the request is bound to ctx
+func (c *RouterClient) RebootContext(ctx context.Context) error {
 	type RebootRequest struct {
 		XMLName xml.Name `xml:"request"`
 		Control int      `xml:"Control"`
@@ -248,29 +272,12 @@ func (c RouterClient) Reboot() error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", c.routerURL+controlURL, bytes.NewReader(reboot))
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
-	req.Header.Add("Content-Length", strconv.Itoa(len(reboot)))
-	req.Header.Add(requestVerificationToken, c.requestVerificationToken)
-	response, err := c.client.Do(req)
-
+	_, err = c.doWithRelogin(ctx, "POST", controlURL, formContentType, reboot)
 	if err != nil {
-		return err
+		return fmt.Errorf("error rebooting router: %w", err)
 	}
 
-	type ErrorReponse struct {
-		XMLName xml.Name `xml:"error"`
-		Code    int      `xml:"code"`
-		Message string   `xml:"message"`
-	}
-
-	responseData, _ := ioutil.ReadAll(response.Body)
-	e := ErrorReponse{}
-	err = xml.Unmarshal(responseData, &e)
-
-	if err == nil {
-		return fmt.Errorf("error rebooting router, response %v", e)
-	}
+	c.setLoggedIn(false)
 
 	return nil
 
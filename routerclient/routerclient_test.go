@@ -40,34 +40,51 @@ func TestCanGetSignal(t *testing.T) {
 		t.Errorf("error getting signal stats %q", err)
 	}
 	assert.EqualValues(t, Signal{
-		RSRQ: -14,
-		RSRP: -86,
-		RSSI: -61,
-		SINR: 10,
-		Bandwidth: struct {
-			Upload   int
-			Download int
-		}{
-			Upload:   15,
-			Download: 15,
+		RSRQ: floatPtr(-14),
+		RSRP: floatPtr(-86),
+		RSSI: floatPtr(-61),
+		SINR: floatPtr(10),
+		Bandwidth: signalBandwidth{
+			Upload:   floatPtr(15),
+			Download: floatPtr(15),
 		},
-		Power: struct {
-			PUSCH int
-			PUCCH int
-			SRS   int
-			PRACH int
-		}{
-			PUSCH: 8,
-			PUCCH: -5,
-			SRS:   0,
-			PRACH: -4,
+		Power: signalPower{
+			PUSCH: floatPtr(8),
+			PUCCH: floatPtr(-5),
+			SRS:   floatPtr(0),
+			PRACH: floatPtr(-4),
 		},
-		EARFCN: struct {
-			Uplink   int
-			Downlink int
-		}{
-			Uplink:   21025,
-			Downlink: 3025,
+		EARFCN: signalEARFCN{
+			Uplink:   intPtr(21025),
+			Downlink: intPtr(3025),
+		},
+		Carrier: &Carrier{
+			Band:     7,
+			Duplex:   DuplexFDD,
+			Downlink: floatPtr(2647.5),
+			Uplink:   floatPtr(2527.5),
+		},
+		Mode:     NetworkModeLTE,
+		Band:     intPtr(7),
+		PLMN:     "26003",
+		TAC:      intPtr(57332),
+		PCI:      intPtr(43),
+		CellID:   intPtr(44294436),
+		ENodeBID: intPtr(173025),
+		Sector:   intPtr(36),
+		MCS: signalMCS{
+			Uplink: []CarrierMCS{{Carrier: 1, Codeword: 0, MCS: 22}},
+			Downlink: []CarrierMCS{
+				{Carrier: 1, Codeword: 0, MCS: 25},
+				{Carrier: 1, Codeword: 1, MCS: 25},
+			},
+		},
+		Neighbours: []NeighbourCell{
+			{Number: 1, PCI: 42},
+			{Number: 2, PCI: 19},
+			{Number: 3, PCI: 43},
+			{Number: 4, PCI: 44},
+			{Number: 5, PCI: 20},
 		},
 	}, signal)
 }
@@ -89,6 +106,7 @@ func TestErrorDuringReboot(t *testing.T) {
 
 	assert.Nil(t, err, "error creating RouterClient %q", err)
 
+	client.tokens = []string{"25ae2067cf278b183daab21a32d133e5"}
 	err = client.Reboot()
 	assert.NotNil(t, err, "reboot should trigger error")
 }
@@ -103,6 +121,22 @@ func TestRouterClientRequiresPassword(t *testing.T) {
 	assert.EqualError(t, err, "password cannot be empty")
 }
 
+func TestAnonymousRouterClientCannotLogIn(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no request expected, got %s", r.URL.RequestURI())
+	}))
+	defer ts.Close()
+
+	client, err := NewAnonymousRouterClient(ts.URL)
+	assert.Nil(t, err, "error creating RouterClient %q", err)
+
+	err = client.Login()
+	assert.Equal(t, ErrNoCredentials, err)
+
+	_, err = NewAnonymousRouterClient("")
+	assert.EqualError(t, err, "routerURL cannot be empty")
+}
+
 func TestRouterClientRequiresUrl(t *testing.T) {
 	_, err := NewRouterClient("", "user", "pass")
 	assert.EqualError(t, err, "routerURL cannot be empty")
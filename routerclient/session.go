@@ -0,0 +1,190 @@
+package routerclient
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ io.Closer = (*RouterClient)(nil)
+
+// LoginState describes the login state reported by router
+type LoginState struct {
+	// State is 0 when the session is logged in, -1 when it is not
+	State    int
+	Username string
+	// PasswordType and ExternPasswordType tell how the router expects the password to be sent,
+	// PasswordType is -1 when router does not report it
+	PasswordType       int
+	ExternPasswordType int
+	RemainingWait      time.Duration
+	// RSAPaddingType tells which padding router expects in RSA encrypted requests
+	RSAPaddingType int
+	// Locked is set when router refuses login attempts for RemainingWait
+	Locked bool
+}
+
+// LoggedIn reports whether the session is logged in
+func (s LoginState) LoggedIn() bool {
+	return s.State == 0
+}
+
+// doWithRelogin sends request like do, but when the router reports that the session has expired
+// it logs in again and retries the request once.
+// Only clients which have already logged in successfully are logged in again.
+func (c *RouterClient) doWithRelogin(ctx context.Context, method string, path string, contentType string, body []byte) ([]byte, error) {
+	return c.withRelogin(ctx, func() ([]byte, error) {
+		return c.doShared(ctx, method, path, contentType, body)
+	})
+}
+
+// withRelogin calls request, logging in again and calling it once more when the session has expired
+func (c *RouterClient) withRelogin(ctx context.Context, request func() ([]byte, error)) ([]byte, error) {
+	loggedIn, generation := c.session()
+	responseData, err := request()
+	if err == nil || !loggedIn || !errors.Is(err, ErrSessionTimeout) {
+		return responseData, err
+	}
+
+	if loginErr := c.relogin(ctx, generation); loginErr != nil {
+		return nil, fmt.Errorf("session expired and login failed: %w", loginErr)
+	}
+
+	return request()
+}
+
+// doShared sends request like do, waiting for the login in progress to finish first
+func (c *RouterClient) doShared(ctx context.Context, method string, path string, contentType string, body []byte) ([]byte, error) {
+	c.sessionMu.RLock()
+	defer c.sessionMu.RUnlock()
+
+	return c.do(ctx, method, path, contentType, body)
+}
+
+// relogin logs in again unless the session of given generation
+// has already been replaced by another goroutine in the meantime
+func (c *RouterClient) relogin(ctx context.Context, generation uint64) error {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if _, current := c.session(); current != generation {
+		return nil
+	}
+
+	c.logf("session expired, logging in again")
+	return c.login(ctx)
+}
+
+// session returns whether the client has logged in and the number of logins made so far
+func (c *RouterClient) session() (bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loggedIn, c.generation
+}
+
+func (c *RouterClient) setLoggedIn(loggedIn bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loggedIn = loggedIn
+}
+
+// LoginState fetches login state of the current session from router
+func (c *RouterClient) LoginState() (LoginState, error) {
+	return c.LoginStateContext(context.Background())
+}
+
+// LoginStateContext is like LoginState, but the request is bound to ctx
+func (c *RouterClient) LoginStateContext(ctx context.Context) (LoginState, error) {
+	c.sessionMu.RLock()
+	defer c.sessionMu.RUnlock()
+
+	return c.loginState(ctx)
+}
+
+func (c *RouterClient) loginState(ctx context.Context) (LoginState, error) {
+	responseData, err := c.do(ctx, "GET", stateLoginURL, "", nil)
+	if err != nil {
+		return LoginState{}, err
+	}
+
+	type StateLoginResponse struct {
+		State              int    `xml:"State"`
+		Username           string `xml:"Username"`
+		PasswordType       string `xml:"password_type"`
+		ExternPasswordType int    `xml:"extern_password_type"`
+		RemainWaitTime     int    `xml:"remain_wait_time"`
+		RSAPaddingType     int    `xml:"rsapadingtype"`
+		LockStatus         int    `xml:"lockstatus"`
+	}
+
+	v := StateLoginResponse{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return LoginState{}, err
+	}
+
+	// missing password type must not be mistaken for type 0, the oldest one
+	passwordType, err := strconv.Atoi(strings.TrimSpace(v.PasswordType))
+	if err != nil {
+		passwordType = passwordTypeUnknown
+	}
+
+	return LoginState{
+		State:              v.State,
+		Username:           v.Username,
+		PasswordType:       passwordType,
+		ExternPasswordType: v.ExternPasswordType,
+		RemainingWait:      time.Duration(v.RemainWaitTime) * time.Second,
+		RSAPaddingType:     v.RSAPaddingType,
+		Locked:             v.LockStatus == 1,
+	}, nil
+}
+
+// Logout ends the router session, freeing one of the few admin sessions router allows.
+// Session which has already expired is not reported as an error.
+func (c *RouterClient) Logout() error {
+	return c.LogoutContext(context.Background())
+}
+
+// LogoutContext is like Logout, but the request is bound to ctx
+func (c *RouterClient) LogoutContext(ctx context.Context) error {
+	type LogoutRequest struct {
+		XMLName xml.Name `xml:"request"`
+		Logout  int      `xml:"Logout"`
+	}
+
+	logout, err := xml.Marshal(LogoutRequest{Logout: 1})
+	if err != nil {
+		return err
+	}
+
+	c.setLoggedIn(false)
+
+	_, err = c.doShared(ctx, "POST", logoutURL, formContentType, append([]byte(xml.Header), logout...))
+	if err != nil && !errors.Is(err, ErrSessionTimeout) {
+		return err
+	}
+
+	return nil
+}
+
+// Close logs out when the client has logged in, it implements io.Closer.
+// With session cache enabled the session is saved for later use instead.
+func (c *RouterClient) Close() error {
+	if loggedIn, _ := c.session(); !loggedIn {
+		return nil
+	}
+
+	if c.sessionCache != "" {
+		c.sessionMu.RLock()
+		defer c.sessionMu.RUnlock()
+		return c.saveSession()
+	}
+
+	return c.Logout()
+}
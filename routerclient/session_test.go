@@ -0,0 +1,267 @@
+package routerclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRouter is a minimal router accepting any login and dropping the session on demand
+type fakeRouter struct {
+	mu          sync.Mutex
+	loggedIn    bool
+	sessions    int
+	session     string
+	logins      int
+	signalCalls int
+	rebootCalls int
+	logouts     int
+}
+
+func (f *fakeRouter) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loggedIn = false
+}
+
+// authenticated reports whether request belongs to the logged in session
+func (f *fakeRouter) authenticated(r *http.Request) bool {
+	c, err := r.Cookie("SessionID")
+	return f.loggedIn && err == nil && c.Value == f.session
+}
+
+func (f *fakeRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.RequestURI() {
+	case "/":
+		f.sessions++
+		http.SetCookie(w, &http.Cookie{Name: "SessionID", Value: fmt.Sprintf("session%d", f.sessions), Path: "/"})
+	case "/api/webserver/token":
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvzS7xTD7LGjRhXAtLCQRYWUKc5YdaRuzJJ</token></response>")
+	case "/api/user/challenge_login":
+		w.Header().Add("__RequestVerificationToken", "25ae2067cf278b183daab21a32d133e5")
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><iterations>100</iterations><servernonce>3325010dd3ff01f13ae515b4d8705c62ee34c019c64f83e059e3a6e3376f9b51Lpcw0a320YeprpYH8kURAUwfyTbYtHUA</servernonce><modeselected>1</modeselected><salt>fd4b1e6ad1b05db6ff288928fed3005ef4fdc9ade8be276220a8f41adcccda29</salt><newType>0</newType></response>")
+	case "/api/user/authentication_login":
+		f.loggedIn = true
+		f.session = fmt.Sprintf("session%d", f.sessions)
+		f.logins++
+		w.Header().Add("__RequestVerificationToken", "35ae2067cf278b183daab21a32d133e5")
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response></response>")
+	case "/api/device/signal":
+		if !f.authenticated(r) {
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>125002</code>\n<message/>\n</error>\n")
+			return
+		}
+		f.signalCalls++
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<rsrq>-14dB</rsrq>\n<rsrp>-86dBm</rsrp>\n<rssi>-61dBm</rssi>\n<sinr>10dB</sinr>\n</response>\n")
+	case "/api/user/state-login":
+		state := -1
+		if f.authenticated(r) {
+			state = 0
+		}
+		fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><State>%d</State><Username>admin</Username><password_type>4</password_type><extern_password_type>1</extern_password_type><firstlogin>1</firstlogin><remain_wait_time>0</remain_wait_time><lockstatus>0</lockstatus><accounts_number>1</accounts_number><wifipwdsamewithwebpwd>0</wifipwdsamewithwebpwd><rsapadingtype>1</rsapadingtype></response>", state)
+	case "/api/user/logout":
+		if !f.authenticated(r) {
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>100003</code>\n<message/>\n</error>\n")
+			return
+		}
+		f.loggedIn = false
+		f.logouts++
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+	case "/api/device/control":
+		if !f.authenticated(r) {
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>100003</code>\n<message/>\n</error>\n")
+			return
+		}
+		f.rebootCalls++
+		f.loggedIn = false
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSignalStatsLogsInAgainAfterSessionExpired(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	signal, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+	assert.Equal(t, floatPtr(-86), signal.RSRP)
+
+	router.expire()
+
+	signal, err = client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats after session expired: %q", err)
+	assert.Equal(t, floatPtr(-86), signal.RSRP)
+	assert.Equal(t, 2, router.logins)
+	assert.Equal(t, 2, router.signalCalls)
+}
+
+func TestRebootLogsInAgainAfterSessionExpired(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	router.expire()
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting after session expired: %q", err)
+	assert.Equal(t, 2, router.logins)
+	assert.Equal(t, 1, router.rebootCalls)
+}
+
+func TestRequestIsRetriedOnlyOnce(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RequestURI() == "/api/device/signal" {
+			router.expire()
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	_, err = client.GetSignalStats()
+	assert.True(t, errors.Is(err, ErrSessionTimeout), "session error expected, got %q", err)
+	assert.Equal(t, 2, router.logins)
+}
+
+func TestNoLoginWithoutPreviousSession(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	_, err = client.GetSignalStats()
+	assert.True(t, errors.Is(err, ErrSessionTimeout), "session error expected, got %q", err)
+	assert.Equal(t, 0, router.logins)
+}
+
+func TestLoginState(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	state, err := client.LoginState()
+	assert.Nil(t, err, "error getting login state: %q", err)
+	assert.False(t, state.LoggedIn())
+	assert.Equal(t, LoginState{State: -1, Username: "admin", PasswordType: 4, ExternPasswordType: 1, RSAPaddingType: 1}, state)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	state, err = client.LoginState()
+	assert.Nil(t, err, "error getting login state: %q", err)
+	assert.True(t, state.LoggedIn())
+}
+
+func TestLogout(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	err = client.Logout()
+	assert.Nil(t, err, "error logging out: %q", err)
+	assert.Equal(t, 1, router.logouts)
+
+	_, err = client.GetSignalStats()
+	assert.True(t, errors.Is(err, ErrSessionTimeout), "session error expected after logout, got %q", err)
+	assert.Equal(t, 1, router.logins, "client should not log in again after logout")
+}
+
+func TestLogoutIgnoresExpiredSession(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	router.expire()
+
+	err = client.Logout()
+	assert.Nil(t, err, "error logging out: %q", err)
+}
+
+func TestCloseLogsOutOnlyWhenLoggedIn(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+	assert.Equal(t, 0, router.logouts)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+	assert.Equal(t, 1, router.logouts)
+
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+	assert.Equal(t, 1, router.logouts)
+}
+
+func TestCloseAfterRebootDoesNotLogOut(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+
+	err = client.Close()
+	assert.Nil(t, err, "error closing client: %q", err)
+	assert.Equal(t, 0, router.logouts)
+}
@@ -0,0 +1,398 @@
+package routerclient
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type signalBandwidth struct {
+	Upload   *float64 `json:"Upload"`
+	Download *float64 `json:"Download"`
+}
+
+type signalEARFCN struct {
+	Uplink   *int `json:"Uplink"`
+	Downlink *int `json:"Downlink"`
+}
+
+type signalNRARFCN struct {
+	Uplink   *int `json:"Uplink"`
+	Downlink *int `json:"Downlink"`
+}
+
+type signalPower struct {
+	PUSCH *float64 `json:"PUSCH"`
+	PUCCH *float64 `json:"PUCCH"`
+	SRS   *float64 `json:"SRS"`
+	PRACH *float64 `json:"PRACH"`
+}
+
+// Signal stores signal parameters. Values not reported by router are nil.
+type Signal struct {
+	RSRQ      *float64        `json:"RSRQ"`
+	RSRP      *float64        `json:"RSRP"`
+	RSSI      *float64        `json:"RSSI"`
+	SINR      *float64        `json:"SINR"`
+	Bandwidth signalBandwidth `json:"Bandwidth"`
+	Power     signalPower     `json:"Power"`
+	EARFCN    signalEARFCN    `json:"EARFCN"`
+	// Carrier is LTE band and centre frequencies derived from EARFCN
+	Carrier *Carrier `json:"Carrier"`
+
+	// 3G received signal code power and energy per chip to interference ratio
+	RSCP *float64 `json:"RSCP"`
+	ECIO *float64 `json:"ECIO"`
+
+	Mode NetworkMode `json:"Mode"`
+	Band *int        `json:"Band"`
+	PLMN string      `json:"PLMN"`
+	TAC  *int        `json:"TAC"`
+	PCI  *int        `json:"PCI"`
+	// CellID is the E-UTRAN cell identity, made of eNodeB ID and sector (cell) number
+	CellID   *int `json:"CellID"`
+	ENodeBID *int `json:"ENodeBID"`
+	Sector   *int `json:"Sector"`
+
+	// 5G models report NR channel as well, NRCarrier is derived from it
+	NRARFCN   *signalNRARFCN `json:"NRARFCN,omitempty"`
+	NRCarrier *NRCarrier     `json:"NRCarrier,omitempty"`
+
+	MCS        signalMCS       `json:"MCS"`
+	Neighbours []NeighbourCell `json:"Neighbours"`
+
+	// Warnings lists values router reported in unexpected format, these values are nil
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+// NetworkMode is the radio access technology router is connected with
+type NetworkMode int
+
+// Network modes reported by router in signal stats
+const (
+	NetworkModeUnknown NetworkMode = -1
+	NetworkModeGSM     NetworkMode = 0
+	NetworkModeWCDMA   NetworkMode = 2
+	NetworkModeLTE     NetworkMode = 7
+)
+
+var networkModeNames = map[NetworkMode]string{
+	NetworkModeUnknown: "unknown",
+	NetworkModeGSM:     "GSM",
+	NetworkModeWCDMA:   "WCDMA",
+	NetworkModeLTE:     "LTE",
+}
+
+func (m NetworkMode) String() string {
+	if name, ok := networkModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("mode %d", int(m))
+}
+
+// MarshalJSON encodes the mode as its name
+func (m NetworkMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes the mode from its name
+func (m *NetworkMode) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	for mode, modeName := range networkModeNames {
+		if name == modeName {
+			*m = mode
+			return nil
+		}
+	}
+
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "mode ")); err == nil {
+		*m = NetworkMode(n)
+		return nil
+	}
+
+	return fmt.Errorf("unknown network mode %q", name)
+}
+
+// CarrierMCS is modulation and coding scheme used on a carrier, downlink reports it per codeword
+type CarrierMCS struct {
+	Carrier  int `json:"Carrier"`
+	Codeword int `json:"Codeword"`
+	MCS      int `json:"MCS"`
+}
+
+type signalMCS struct {
+	Uplink   []CarrierMCS `json:"Uplink"`
+	Downlink []CarrierMCS `json:"Downlink"`
+}
+
+// NeighbourCell is a cell router can see besides the serving one
+type NeighbourCell struct {
+	Number int `json:"Number"`
+	PCI    int `json:"PCI"`
+}
+
+// valueParser parses values reported by router, collecting warnings about the ones in unexpected format
+type valueParser struct {
+	warnings []string
+}
+
+func (p *valueParser) warn(field string, v string) {
+	p.warnings = append(p.warnings, fmt.Sprintf("%s: unexpected value %q", field, v))
+}
+
+// measurement parses value with optional unit, e.g. "-10.5dB". Values at the limit of
+// the measurement range are reported with comparison prefix, e.g. ">=-51dBm", the limit is returned for them.
+// Empty value means it was not reported and results in nil.
+func (p *valueParser) measurement(field string, v string, unit string) *float64 {
+	s := strings.TrimSpace(v)
+	if s == "" {
+		return nil
+	}
+
+	s = strings.TrimSpace(strings.TrimSuffix(s, unit))
+	for _, prefix := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(s, prefix) {
+			s = strings.TrimSpace(s[len(prefix):])
+			break
+		}
+	}
+
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		p.warn(field, v)
+		return nil
+	}
+
+	return &val
+}
+
+// integer parses whole number, empty value results in nil
+func (p *valueParser) integer(field string, v string) *int {
+	s := strings.TrimSpace(v)
+	if s == "" {
+		return nil
+	}
+
+	val, err := strconv.Atoi(s)
+	if err != nil {
+		p.warn(field, v)
+		return nil
+	}
+
+	return &val
+}
+
+func (p *valueParser) networkMode(v string) NetworkMode {
+	mode := p.integer("mode", v)
+	if mode == nil {
+		return NetworkModeUnknown
+	}
+	return NetworkMode(*mode)
+}
+
+// keyValues splits space separated list of key:value pairs, e.g. "DL:3025 UL:21025"
+func (p *valueParser) keyValues(field string, v string) map[string]string {
+	values := map[string]string{}
+	for _, f := range strings.Fields(v) {
+		kv := strings.SplitN(f, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			p.warn(field, f)
+			continue
+		}
+		values[kv[0]] = kv[1]
+	}
+	return values
+}
+
+func (p *valueParser) earfcn(v string) signalEARFCN {
+	values := p.keyValues("earfcn", v)
+	return signalEARFCN{
+		Uplink:   p.integer("earfcn", values["UL"]),
+		Downlink: p.integer("earfcn", values["DL"]),
+	}
+}
+
+func (p *valueParser) nrarfcn(v string) *signalNRARFCN {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+
+	values := p.keyValues("nrearfcn", v)
+	return &signalNRARFCN{
+		Uplink:   p.integer("nrearfcn", values["UL"]),
+		Downlink: p.integer("nrearfcn", values["DL"]),
+	}
+}
+
+func (p *valueParser) power(v string) signalPower {
+	values := p.keyValues("txpower", v)
+	return signalPower{
+		PUSCH: p.measurement("txpower", values["PPusch"], "dBm"),
+		PUCCH: p.measurement("txpower", values["PPucch"], "dBm"),
+		SRS:   p.measurement("txpower", values["PSrs"], "dBm"),
+		PRACH: p.measurement("txpower", values["PPrach"], "dBm"),
+	}
+}
+
+var mcsRegexp = regexp.MustCompile(`^mcs(?:Up|Down)Carrier(\d+)(?:Code(\d+))?:(\d+)$`)
+
+// mcs parses MCS list, e.g. "mcsDownCarrier1Code0:25 mcsDownCarrier1Code1:25"
+func (p *valueParser) mcs(field string, v string) []CarrierMCS {
+	mcs := []CarrierMCS{}
+	for _, f := range strings.Fields(v) {
+		m := mcsRegexp.FindStringSubmatch(f)
+		if m == nil {
+			p.warn(field, f)
+			continue
+		}
+
+		carrier, err1 := strconv.Atoi(m[1])
+		codeword, err2 := strconv.Atoi("0" + m[2])
+		value, err3 := strconv.Atoi(m[3])
+		if err1 != nil || err2 != nil || err3 != nil {
+			p.warn(field, f)
+			continue
+		}
+		mcs = append(mcs, CarrierMCS{Carrier: carrier, Codeword: codeword, MCS: value})
+	}
+	return mcs
+}
+
+var neighbourRegexp = regexp.MustCompile(`^(?:No(\d+):(\d+))*$`)
+var neighbourCellRegexp = regexp.MustCompile(`No(\d+):(\d+)`)
+
+// neighbourCells parses neighbour cell list, e.g. "No1:42No2:19No3:43"
+func (p *valueParser) neighbourCells(v string) []NeighbourCell {
+	cells := []NeighbourCell{}
+	s := strings.TrimSpace(v)
+	if !neighbourRegexp.MatchString(s) {
+		p.warn("nei_cellid", v)
+		return cells
+	}
+
+	for _, m := range neighbourCellRegexp.FindAllStringSubmatch(s, -1) {
+		number, err1 := strconv.Atoi(m[1])
+		pci, err2 := strconv.Atoi(m[2])
+		if err1 != nil || err2 != nil {
+			p.warn("nei_cellid", v)
+			continue
+		}
+		cells = append(cells, NeighbourCell{Number: number, PCI: pci})
+	}
+	return cells
+}
+
+// parseSignal parses response of the signal endpoint
+func parseSignal(responseData []byte) (Signal, error) {
+	type SignalResponse struct {
+		RSRQ              string `xml:"rsrq"`
+		RSRP              string `xml:"rsrp"`
+		RSSI              string `xml:"rssi"`
+		SINR              string `xml:"sinr"`
+		UploadBandwidth   string `xml:"ulbandwidth"`
+		DownloadBandwidth string `xml:"dlbandwidth"`
+		TXPower           string `xml:"txpower"`
+		EARFCN            string `xml:"earfcn"`
+		NRARFCN           string `xml:"nrearfcn"`
+		RSCP              string `xml:"rscp"`
+		ECIO              string `xml:"ecio"`
+		Mode              string `xml:"mode"`
+		Band              string `xml:"band"`
+		PLMN              string `xml:"plmn"`
+		TAC               string `xml:"tac"`
+		PCI               string `xml:"pci"`
+		CellID            string `xml:"cell_id"`
+		UploadMCS         string `xml:"ul_mcs"`
+		DownloadMCS       string `xml:"dl_mcs"`
+		Neighbours        string `xml:"nei_cellid"`
+	}
+
+	v := SignalResponse{}
+	err := xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return Signal{}, err
+	}
+
+	p := &valueParser{}
+	signal := Signal{
+		RSRQ: p.measurement("rsrq", v.RSRQ, "dB"),
+		RSRP: p.measurement("rsrp", v.RSRP, "dBm"),
+		RSSI: p.measurement("rssi", v.RSSI, "dBm"),
+		SINR: p.measurement("sinr", v.SINR, "dB"),
+		Bandwidth: signalBandwidth{
+			Upload:   p.measurement("ulbandwidth", v.UploadBandwidth, "MHz"),
+			Download: p.measurement("dlbandwidth", v.DownloadBandwidth, "MHz"),
+		},
+		EARFCN:  p.earfcn(v.EARFCN),
+		NRARFCN: p.nrarfcn(v.NRARFCN),
+		Power:   p.power(v.TXPower),
+		RSCP:    p.measurement("rscp", v.RSCP, "dBm"),
+		ECIO:    p.measurement("ecio", v.ECIO, "dB"),
+		Mode:    p.networkMode(v.Mode),
+		Band:    p.integer("band", v.Band),
+		PLMN:    strings.TrimSpace(v.PLMN),
+		TAC:     p.integer("tac", v.TAC),
+		PCI:     p.integer("pci", v.PCI),
+		CellID:  p.integer("cell_id", v.CellID),
+		MCS: signalMCS{
+			Uplink:   p.mcs("ul_mcs", v.UploadMCS),
+			Downlink: p.mcs("dl_mcs", v.DownloadMCS),
+		},
+		Neighbours: p.neighbourCells(v.Neighbours),
+	}
+
+	// the lowest 8 bits of E-UTRAN cell identity are the sector, the rest is eNodeB ID
+	if signal.CellID != nil {
+		eNodeBID, sector := *signal.CellID/256, *signal.CellID%256
+		signal.ENodeBID, signal.Sector = &eNodeBID, &sector
+	}
+
+	// EARFCN is reported only in LTE mode, 3G and 2G channel numbers are not converted
+	if signal.Mode != NetworkModeGSM && signal.Mode != NetworkModeWCDMA {
+		carrier, err := signal.EARFCN.Carrier()
+		if err != nil {
+			p.warnings = append(p.warnings, fmt.Sprintf("earfcn: %v", err))
+		}
+		signal.Carrier = carrier
+
+		if carrier != nil && signal.Band != nil && *signal.Band != carrier.Band {
+			p.warnings = append(p.warnings, fmt.Sprintf("band: router reports band %d, EARFCN %d is in band %d",
+				*signal.Band, *signal.EARFCN.Downlink, carrier.Band))
+		}
+	}
+
+	if signal.NRARFCN != nil {
+		carrier, err := signal.NRARFCN.Carrier()
+		if err != nil {
+			p.warnings = append(p.warnings, fmt.Sprintf("nrearfcn: %v", err))
+		}
+		signal.NRCarrier = carrier
+	}
+
+	signal.Warnings = p.warnings
+	return signal, nil
+}
+
+// GetSignalStats connects to router and fetches current signal stats
+func (c *RouterClient) GetSignalStats() (Signal, error) {
+	return c.GetSignalStatsContext(context.Background())
+}
+
+// GetSignalStatsContext is like GetSignalStats, but the request is bound to ctx
+func (c *RouterClient) GetSignalStatsContext(ctx context.Context) (Signal, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", signalURL, "", nil)
+	if err != nil {
+		return Signal{}, err
+	}
+
+	return parseSignal(responseData)
+}
@@ -0,0 +1,42 @@
+package routerclient
+
+import (
+	"math"
+	"testing"
+)
+
+func FuzzSignalParsers(f *testing.F) {
+	for _, seed := range []string{
+		"-86dBm", "-10.5dB", ">=-51dBm", "15MHz", "", "B", "DL:3025 UL:21025",
+		"PPusch:8dBm PPucch:-5dBm PSrs:0dBm PPrach:-4dBm",
+		"mcsDownCarrier1Code0:25 mcsDownCarrier1Code1:25 ", "No1:42No2:19No3:43No4:44No5:20",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, v string) {
+		p := &valueParser{}
+		for _, unit := range []string{"dB", "dBm", "MHz"} {
+			if m := p.measurement("test", v, unit); m != nil && (math.IsNaN(*m) || math.IsInf(*m, 0)) {
+				t.Errorf("measurement %q parsed as %v", v, *m)
+			}
+		}
+		p.integer("test", v)
+		p.networkMode(v)
+		p.earfcn(v)
+		p.power(v)
+		p.mcs("test", v)
+		p.neighbourCells(v)
+		p.code("test", v, networkTypes)
+
+		// the same value in every element of the response
+		response := "<response>"
+		for _, name := range []string{"rsrq", "rsrp", "rssi", "sinr", "ulbandwidth", "dlbandwidth", "txpower", "earfcn", "nrearfcn", "rscp", "ecio",
+			"mode", "band", "plmn", "tac", "pci", "cell_id", "ul_mcs", "dl_mcs", "nei_cellid"} {
+			response += "<" + name + ">" + v + "</" + name + ">"
+		}
+		response += "</response>"
+
+		parseSignal([]byte(response))
+	})
+}
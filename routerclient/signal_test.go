@@ -0,0 +1,166 @@
+package routerclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCanParseMeasurements(t *testing.T) {
+	tests := []struct {
+		value    string
+		unit     string
+		expected *float64
+		warning  bool
+	}{
+		{"-86dBm", "dBm", floatPtr(-86), false},
+		{"-10.5dB", "dB", floatPtr(-10.5), false},
+		{">=-51dBm", "dBm", floatPtr(-51), false},
+		{"<-140 dBm", "dBm", floatPtr(-140), false},
+		{"1.4MHz", "MHz", floatPtr(1.4), false},
+		{" 20 ", "MHz", floatPtr(20), false},
+		{"", "dB", nil, false},
+		{"B", "dB", nil, true},
+		{"dB", "dB", nil, true},
+		{"-86dBm", "dB", nil, true},
+		{"NaNdB", "dB", nil, true},
+		{"Inf", "dB", nil, true},
+	}
+
+	for _, tt := range tests {
+		p := &valueParser{}
+		assert.Equal(t, tt.expected, p.measurement("test", tt.value, tt.unit), "value %q", tt.value)
+		assert.Equal(t, tt.warning, len(p.warnings) > 0, "warning for value %q", tt.value)
+	}
+}
+
+func TestCanParseMCS(t *testing.T) {
+	p := &valueParser{}
+
+	assert.Equal(t, []CarrierMCS{
+		{Carrier: 1, Codeword: 0, MCS: 27},
+		{Carrier: 1, Codeword: 1, MCS: 26},
+		{Carrier: 2, Codeword: 0, MCS: 9},
+	}, p.mcs("dl_mcs", "mcsDownCarrier1Code0:27 mcsDownCarrier1Code1:26 mcsDownCarrier2Code0:9 "))
+	assert.Equal(t, []CarrierMCS{{Carrier: 1, MCS: 22}, {Carrier: 2, MCS: 3}}, p.mcs("ul_mcs", "mcsUpCarrier1:22 mcsUpCarrier2:3"))
+	assert.Equal(t, []CarrierMCS{}, p.mcs("ul_mcs", ""), "empty list should not be encoded as null")
+	assert.Empty(t, p.warnings)
+
+	assert.Equal(t, []CarrierMCS{{Carrier: 1, MCS: 22}}, p.mcs("ul_mcs", "mcsUpCarrier1:22 garbage"))
+	assert.Equal(t, []string{`ul_mcs: unexpected value "garbage"`}, p.warnings)
+}
+
+func TestCanParseNeighbourCells(t *testing.T) {
+	p := &valueParser{}
+
+	assert.Equal(t, []NeighbourCell{{Number: 1, PCI: 42}, {Number: 2, PCI: 19}}, p.neighbourCells("No1:42No2:19"))
+	assert.Equal(t, []NeighbourCell{}, p.neighbourCells(""))
+	assert.Empty(t, p.warnings)
+
+	assert.Equal(t, []NeighbourCell{}, p.neighbourCells("No1:42,No2"))
+	assert.Len(t, p.warnings, 1)
+}
+
+func TestMissingAndInvalidValuesAreReported(t *testing.T) {
+	signal, err := parseSignal([]byte("<response><rsrq>-10.5dB</rsrq><rsrp>>=-44dBm</rsrp><rssi>x</rssi><sinr></sinr><earfcn>DL:3025 UL</earfcn><txpower>PPusch:8dBm</txpower><cell_id>abc</cell_id><mode>7</mode></response>"))
+
+	assert.Nil(t, err, "error parsing signal: %q", err)
+	assert.Equal(t, floatPtr(-10.5), signal.RSRQ)
+	assert.Equal(t, floatPtr(-44), signal.RSRP)
+	assert.Nil(t, signal.RSSI)
+	assert.Nil(t, signal.SINR, "missing value should be nil rather than 0")
+	assert.Equal(t, intPtr(3025), signal.EARFCN.Downlink)
+	assert.Nil(t, signal.EARFCN.Uplink)
+	assert.Equal(t, floatPtr(8), signal.Power.PUSCH)
+	assert.Nil(t, signal.Power.PUCCH)
+	assert.Nil(t, signal.CellID)
+	assert.Nil(t, signal.ENodeBID)
+	assert.Nil(t, signal.Band)
+	assert.Equal(t, NetworkModeLTE, signal.Mode)
+	assert.Equal(t, []string{
+		`rssi: unexpected value "x"`,
+		`earfcn: unexpected value "UL"`,
+		`cell_id: unexpected value "abc"`,
+	}, signal.Warnings)
+}
+
+func TestNetworkMode(t *testing.T) {
+	p := &valueParser{}
+	assert.Equal(t, NetworkModeLTE, p.networkMode("7"))
+	assert.Equal(t, NetworkModeWCDMA, p.networkMode("2"))
+	assert.Equal(t, NetworkModeGSM, p.networkMode("0"))
+	assert.Equal(t, NetworkModeUnknown, p.networkMode(""))
+	assert.Equal(t, "mode 11", NetworkMode(11).String())
+
+	for _, mode := range []NetworkMode{NetworkModeLTE, NetworkModeUnknown, NetworkMode(11)} {
+		data, err := json.Marshal(mode)
+		assert.Nil(t, err, "error marshalling: %q", err)
+
+		var decoded NetworkMode
+		err = json.Unmarshal(data, &decoded)
+		assert.Nil(t, err, "error unmarshalling: %q", err)
+		assert.Equal(t, mode, decoded)
+	}
+
+	data, _ := json.Marshal(NetworkModeLTE)
+	assert.Equal(t, `"LTE"`, string(data))
+}
+
+func TestSignalJSONIsStable(t *testing.T) {
+	data, err := json.Marshal(Signal{
+		RSRQ: floatPtr(-14), RSRP: floatPtr(-86), RSSI: floatPtr(-61), SINR: floatPtr(10.5),
+		Bandwidth: signalBandwidth{Upload: floatPtr(15), Download: floatPtr(15)},
+		Power:     signalPower{PUSCH: floatPtr(8), PUCCH: floatPtr(-5), SRS: floatPtr(0), PRACH: floatPtr(-4)},
+		EARFCN:    signalEARFCN{Uplink: intPtr(21025), Downlink: intPtr(3025)},
+		Mode:      NetworkModeLTE, Band: intPtr(7), PLMN: "26003", TAC: intPtr(57332), PCI: intPtr(43),
+		CellID: intPtr(44294436), ENodeBID: intPtr(173025), Sector: intPtr(36),
+		MCS:        signalMCS{Uplink: []CarrierMCS{{Carrier: 1, MCS: 22}}, Downlink: []CarrierMCS{}},
+		Neighbours: []NeighbourCell{{Number: 1, PCI: 42}},
+	})
+
+	assert.Nil(t, err, "error marshalling: %q", err)
+	assert.Equal(t, `{"RSRQ":-14,"RSRP":-86,"RSSI":-61,"SINR":10.5,`+
+		`"Bandwidth":{"Upload":15,"Download":15},"Power":{"PUSCH":8,"PUCCH":-5,"SRS":0,"PRACH":-4},"EARFCN":{"Uplink":21025,"Downlink":3025},"Carrier":null,`+
+		`"RSCP":null,"ECIO":null,"Mode":"LTE","Band":7,"PLMN":"26003","TAC":57332,"PCI":43,"CellID":44294436,"ENodeBID":173025,"Sector":36,`+
+		`"MCS":{"Uplink":[{"Carrier":1,"Codeword":0,"MCS":22}],"Downlink":[]},"Neighbours":[{"Number":1,"PCI":42}]}`, string(data))
+}
+
+func TestCarrierIsDerivedFromEARFCN(t *testing.T) {
+	signal, err := parseSignal([]byte("<response><mode>7</mode><band>3</band><earfcn>DL:1300 UL:19300</earfcn><nrearfcn>DL:627264 UL:627264</nrearfcn></response>"))
+
+	assert.Nil(t, err, "error parsing signal: %q", err)
+	assert.Equal(t, &Carrier{Band: 3, Duplex: DuplexFDD, Downlink: floatPtr(1815), Uplink: floatPtr(1720)}, signal.Carrier)
+	assert.Equal(t, &NRCarrier{Bands: []int{78, 77}, Duplex: DuplexTDD, Downlink: floatPtr(3408.96), Uplink: floatPtr(3408.96)}, signal.NRCarrier)
+	assert.Empty(t, signal.Warnings)
+}
+
+func TestBandMismatchIsReported(t *testing.T) {
+	signal, err := parseSignal([]byte("<response><mode>7</mode><band>3</band><earfcn>DL:6300 UL:24300</earfcn></response>"))
+
+	assert.Nil(t, err, "error parsing signal: %q", err)
+	assert.Equal(t, 20, signal.Carrier.Band)
+	assert.Equal(t, []string{"band: router reports band 3, EARFCN 6300 is in band 20"}, signal.Warnings)
+
+	signal, err = parseSignal([]byte("<response><mode>7</mode><earfcn>DL:99999</earfcn></response>"))
+
+	assert.Nil(t, err, "error parsing signal: %q", err)
+	assert.Nil(t, signal.Carrier)
+	assert.Equal(t, []string{"earfcn: channel number not in any known band: EARFCN 99999"}, signal.Warnings)
+}
+
+func TestCarrierIsNotDerivedIn3G(t *testing.T) {
+	signal, err := parseSignal([]byte("<response><mode>2</mode><earfcn>DL:10700</earfcn></response>"))
+
+	assert.Nil(t, err, "error parsing signal: %q", err)
+	assert.Nil(t, signal.Carrier)
+	assert.Empty(t, signal.Warnings)
+}
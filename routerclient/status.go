@@ -0,0 +1,248 @@
+package routerclient
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// ConnectionStatus is the state of mobile data connection
+type ConnectionStatus int
+
+// Connection states, router reports the reason of failed connection with other codes,
+// these are ConnectionDisconnected
+const (
+	ConnectionUnknown ConnectionStatus = iota
+	ConnectionConnecting
+	ConnectionConnected
+	ConnectionDisconnected
+	ConnectionDisconnecting
+)
+
+var connectionStatusNames = map[ConnectionStatus]string{
+	ConnectionUnknown:       "unknown",
+	ConnectionConnecting:    "connecting",
+	ConnectionConnected:     "connected",
+	ConnectionDisconnected:  "disconnected",
+	ConnectionDisconnecting: "disconnecting",
+}
+
+var connectionStatusCodes = map[int]ConnectionStatus{
+	900: ConnectionConnecting,
+	901: ConnectionConnected,
+	902: ConnectionDisconnected,
+	903: ConnectionDisconnecting,
+}
+
+func (s ConnectionStatus) String() string {
+	if name, ok := connectionStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("status %d", int(s))
+}
+
+// MarshalJSON encodes the status as its name
+func (s ConnectionStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes the status from its name
+func (s *ConnectionStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	for status, statusName := range connectionStatusNames {
+		if name == statusName {
+			*s = status
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown connection status %q", name)
+}
+
+// networkTypes are names of CurrentNetworkTypeEx codes
+var networkTypes = map[int]string{
+	0:    "no service",
+	1:    "GSM",
+	2:    "GPRS",
+	3:    "EDGE",
+	41:   "WCDMA",
+	42:   "HSDPA",
+	43:   "HSUPA",
+	44:   "HSPA",
+	45:   "HSPA+",
+	46:   "DC-HSPA+",
+	61:   "TD-SCDMA",
+	62:   "TD-HSDPA",
+	63:   "TD-HSUPA",
+	64:   "TD-HSPA",
+	65:   "TD-HSPA+",
+	101:  "LTE",
+	1011: "LTE-CA",
+	111:  "NR",
+}
+
+// legacyNetworkTypes are names of CurrentNetworkType codes, reported by older firmware only
+var legacyNetworkTypes = map[int]string{
+	0:  "no service",
+	1:  "GSM",
+	2:  "GPRS",
+	3:  "EDGE",
+	4:  "WCDMA",
+	5:  "HSDPA",
+	6:  "HSUPA",
+	7:  "HSPA",
+	8:  "TD-SCDMA",
+	9:  "HSPA+",
+	17: "HSPA+",
+	18: "HSPA+",
+	19: "LTE",
+	41: "WCDMA",
+	44: "HSPA",
+	45: "HSPA+",
+	46: "DC-HSPA+",
+}
+
+var serviceDomains = map[int]string{
+	0: "no service",
+	1: "CS",
+	2: "PS",
+	3: "CS+PS",
+	4: "searching",
+}
+
+var simStatuses = map[int]string{
+	0:   "invalid",
+	1:   "valid",
+	2:   "invalid for CS",
+	3:   "invalid for PS",
+	4:   "invalid for CS and PS",
+	240: "ROM SIM",
+	255: "missing",
+}
+
+// Status is the connection status reported by router
+type Status struct {
+	Connection ConnectionStatus `json:"Connection"`
+	// ConnectionCode is the raw status, for failed connection it tells the reason
+	ConnectionCode *int   `json:"ConnectionCode"`
+	NetworkType    string `json:"NetworkType"`
+	ServiceDomain  string `json:"ServiceDomain"`
+	Roaming        bool   `json:"Roaming"`
+	SIMStatus      string `json:"SIMStatus"`
+
+	WANIPAddress     string `json:"WANIPAddress"`
+	WANIPv6Address   string `json:"WANIPv6Address"`
+	PrimaryDNS       string `json:"PrimaryDNS"`
+	SecondaryDNS     string `json:"SecondaryDNS"`
+	PrimaryIPv6DNS   string `json:"PrimaryIPv6DNS"`
+	SecondaryIPv6DNS string `json:"SecondaryIPv6DNS"`
+
+	// SignalIcon is the number of bars shown by router, out of MaxSignal
+	SignalIcon  *int `json:"SignalIcon"`
+	MaxSignal   *int `json:"MaxSignal"`
+	WiFiClients *int `json:"WiFiClients"`
+
+	// Warnings lists values router reported in unexpected format
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+// Connected reports whether mobile data connection is up
+func (s Status) Connected() bool {
+	return s.Connection == ConnectionConnected
+}
+
+// code looks up name of the code, unknown codes are reported by their number
+func (p *valueParser) code(field string, v string, names map[int]string) string {
+	c := p.integer(field, v)
+	if c == nil {
+		return ""
+	}
+	if name, ok := names[*c]; ok {
+		return name
+	}
+	return fmt.Sprintf("code %d", *c)
+}
+
+// parseStatus parses response of the monitoring status endpoint
+func parseStatus(responseData []byte) (Status, error) {
+	type StatusResponse struct {
+		ConnectionStatus     string `xml:"ConnectionStatus"`
+		CurrentNetworkType   string `xml:"CurrentNetworkType"`
+		CurrentNetworkTypeEx string `xml:"CurrentNetworkTypeEx"`
+		CurrentServiceDomain string `xml:"CurrentServiceDomain"`
+		RoamingStatus        string `xml:"RoamingStatus"`
+		SimStatus            string `xml:"SimStatus"`
+		WanIPAddress         string `xml:"WanIPAddress"`
+		WanIPv6Address       string `xml:"WanIPv6Address"`
+		PrimaryDNS           string `xml:"PrimaryDns"`
+		SecondaryDNS         string `xml:"SecondaryDns"`
+		PrimaryIPv6DNS       string `xml:"PrimaryIPv6Dns"`
+		SecondaryIPv6DNS     string `xml:"SecondaryIPv6Dns"`
+		SignalIcon           string `xml:"SignalIcon"`
+		MaxSignal            string `xml:"maxsignal"`
+		CurrentWifiUser      string `xml:"CurrentWifiUser"`
+	}
+
+	v := StatusResponse{}
+	err := xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return Status{}, err
+	}
+
+	p := &valueParser{}
+	status := Status{
+		ConnectionCode:   p.integer("ConnectionStatus", v.ConnectionStatus),
+		ServiceDomain:    p.code("CurrentServiceDomain", v.CurrentServiceDomain, serviceDomains),
+		SIMStatus:        p.code("SimStatus", v.SimStatus, simStatuses),
+		WANIPAddress:     strings.TrimSpace(v.WanIPAddress),
+		WANIPv6Address:   strings.TrimSpace(v.WanIPv6Address),
+		PrimaryDNS:       strings.TrimSpace(v.PrimaryDNS),
+		SecondaryDNS:     strings.TrimSpace(v.SecondaryDNS),
+		PrimaryIPv6DNS:   strings.TrimSpace(v.PrimaryIPv6DNS),
+		SecondaryIPv6DNS: strings.TrimSpace(v.SecondaryIPv6DNS),
+		SignalIcon:       p.integer("SignalIcon", v.SignalIcon),
+		MaxSignal:        p.integer("maxsignal", v.MaxSignal),
+		WiFiClients:      p.integer("CurrentWifiUser", v.CurrentWifiUser),
+	}
+
+	if status.ConnectionCode != nil {
+		status.Connection = ConnectionDisconnected
+		if s, ok := connectionStatusCodes[*status.ConnectionCode]; ok {
+			status.Connection = s
+		}
+	}
+
+	// the extended network type tells LTE-CA from LTE, older firmware reports only the legacy one
+	if strings.TrimSpace(v.CurrentNetworkTypeEx) != "" {
+		status.NetworkType = p.code("CurrentNetworkTypeEx", v.CurrentNetworkTypeEx, networkTypes)
+	} else {
+		status.NetworkType = p.code("CurrentNetworkType", v.CurrentNetworkType, legacyNetworkTypes)
+	}
+
+	roaming := p.integer("RoamingStatus", v.RoamingStatus)
+	status.Roaming = roaming != nil && *roaming != 0
+
+	status.Warnings = p.warnings
+	return status, nil
+}
+
+// GetStatus connects to router and fetches connection status
+func (c *RouterClient) GetStatus() (Status, error) {
+	return c.GetStatusContext(context.Background())
+}
+
+// GetStatusContext is like GetStatus, but the request is bound to ctx
+func (c *RouterClient) GetStatusContext(ctx context.Context) (Status, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", statusURL, "", nil)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return parseStatus(responseData)
+}
@@ -0,0 +1,95 @@
+package routerclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanGetStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/monitoring/status", r.URL.RequestURI())
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<ConnectionStatus>901</ConnectionStatus>\n<WifiConnectionStatus></WifiConnectionStatus>\n<SignalStrength></SignalStrength>\n<SignalIcon>4</SignalIcon>\n<CurrentNetworkType>19</CurrentNetworkType>\n<CurrentServiceDomain>3</CurrentServiceDomain>\n<RoamingStatus>0</RoamingStatus>\n<BatteryStatus></BatteryStatus>\n<simlockStatus>0</simlockStatus>\n<WanIPAddress>10.123.45.67</WanIPAddress>\n<WanIPv6Address></WanIPv6Address>\n<PrimaryDns>10.1.1.1</PrimaryDns>\n<SecondaryDns>10.1.1.2</SecondaryDns>\n<PrimaryIPv6Dns></PrimaryIPv6Dns>\n<SecondaryIPv6Dns></SecondaryIPv6Dns>\n<CurrentWifiUser>3</CurrentWifiUser>\n<TotalWifiUser>32</TotalWifiUser>\n<ServiceStatus>2</ServiceStatus>\n<SimStatus>1</SimStatus>\n<WifiStatus>1</WifiStatus>\n<CurrentNetworkTypeEx>1011</CurrentNetworkTypeEx>\n<maxsignal>5</maxsignal>\n<classify>cpe</classify>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	status, err := client.GetStatus()
+
+	assert.Nil(t, err, "error getting status: %q", err)
+	assert.Equal(t, Status{
+		Connection:     ConnectionConnected,
+		ConnectionCode: intPtr(901),
+		NetworkType:    "LTE-CA",
+		ServiceDomain:  "CS+PS",
+		SIMStatus:      "valid",
+		WANIPAddress:   "10.123.45.67",
+		PrimaryDNS:     "10.1.1.1",
+		SecondaryDNS:   "10.1.1.2",
+		SignalIcon:     intPtr(4),
+		MaxSignal:      intPtr(5),
+		WiFiClients:    intPtr(3),
+	}, status)
+	assert.True(t, status.Connected())
+}
+
+func TestConnectionStatusCodes(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected ConnectionStatus
+	}{
+		{"900", ConnectionConnecting},
+		{"901", ConnectionConnected},
+		{"902", ConnectionDisconnected},
+		{"903", ConnectionDisconnecting},
+		{"112", ConnectionDisconnected},
+		{"", ConnectionUnknown},
+		{"x", ConnectionUnknown},
+	}
+
+	for _, tt := range tests {
+		status, err := parseStatus([]byte("<response><ConnectionStatus>" + tt.code + "</ConnectionStatus></response>"))
+		assert.Nil(t, err, "error parsing status: %q", err)
+		assert.Equal(t, tt.expected, status.Connection, "code %q", tt.code)
+	}
+}
+
+func TestStatusOfOlderFirmware(t *testing.T) {
+	status, err := parseStatus([]byte("<response><ConnectionStatus>902</ConnectionStatus><CurrentNetworkType>4</CurrentNetworkType><RoamingStatus>1</RoamingStatus><SimStatus>255</SimStatus><CurrentServiceDomain>7</CurrentServiceDomain></response>"))
+
+	assert.Nil(t, err, "error parsing status: %q", err)
+	assert.Equal(t, "WCDMA", status.NetworkType)
+	assert.True(t, status.Roaming)
+	assert.Equal(t, "missing", status.SIMStatus)
+	assert.Equal(t, "code 7", status.ServiceDomain)
+	assert.Nil(t, status.SignalIcon)
+	assert.False(t, status.Connected())
+}
+
+func TestStatusWarnings(t *testing.T) {
+	status, err := parseStatus([]byte("<response><ConnectionStatus>901</ConnectionStatus><SignalIcon>high</SignalIcon></response>"))
+
+	assert.Nil(t, err, "error parsing status: %q", err)
+	assert.Nil(t, status.SignalIcon)
+	assert.Equal(t, []string{`SignalIcon: unexpected value "high"`}, status.Warnings)
+}
+
+func TestConnectionStatusJSON(t *testing.T) {
+	data, err := json.Marshal(ConnectionConnecting)
+	assert.Nil(t, err, "error marshalling: %q", err)
+	assert.Equal(t, `"connecting"`, string(data))
+
+	var decoded ConnectionStatus
+	err = json.Unmarshal(data, &decoded)
+	assert.Nil(t, err, "error unmarshalling: %q", err)
+	assert.Equal(t, ConnectionConnecting, decoded)
+
+	err = json.Unmarshal([]byte(`"online"`), &decoded)
+	assert.EqualError(t, err, `unknown connection status "online"`)
+}
@@ -0,0 +1,161 @@
+package routerclient
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxTokens is the size of the token pool, router forgets the oldest tokens it handed out,
+// so there is no point in keeping more
+const maxTokens = 16
+
+// tokenHeaders are response headers router uses to hand out new verification tokens.
+// Firmware sending tokens in the one and two headers repeats them in the plain one,
+// so the plain header is used only when the others are missing.
+var tokenHeaders = [][]string{
+	{requestVerificationToken + "one", requestVerificationToken + "two"},
+	{requestVerificationToken},
+}
+
+// updateVerificationTokenFromHeaders adds tokens returned in the response headers to the pool.
+// Single header may carry several tokens separated with #.
+func (c *RouterClient) updateVerificationTokenFromHeaders(httpResponse *http.Response) {
+	var tokens []string
+	for _, headers := range tokenHeaders {
+		for _, header := range headers {
+			for _, value := range httpResponse.Header.Values(header) {
+				for _, token := range strings.Split(value, "#") {
+					if token != "" {
+						tokens = append(tokens, token)
+					}
+				}
+			}
+		}
+		if len(tokens) > 0 {
+			break
+		}
+	}
+
+	if len(tokens) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, token := range tokens {
+		c.pushToken(token)
+	}
+}
+
+// pushToken adds token to the pool unless it is there already, dropping the oldest tokens
+// when the pool is full. Caller must hold mu.
+func (c *RouterClient) pushToken(token string) {
+	for _, t := range c.tokens {
+		if t == token {
+			return
+		}
+	}
+
+	c.tokens = append(c.tokens, token)
+	if len(c.tokens) > maxTokens {
+		c.tokens = c.tokens[len(c.tokens)-maxTokens:]
+	}
+}
+
+// resetTokens drops all tokens from the pool, they are not valid for a new session
+func (c *RouterClient) resetTokens() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = nil
+}
+
+// nextToken takes the newest token from the pool, fetching a new one from router when the pool is empty
+func (c *RouterClient) nextToken(ctx context.Context) (string, error) {
+	for {
+		c.mu.Lock()
+		// the newest token is the least likely to have been forgotten by router
+		if len(c.tokens) > 0 {
+			token := c.tokens[len(c.tokens)-1]
+			c.tokens = c.tokens[:len(c.tokens)-1]
+			c.mu.Unlock()
+			return token, nil
+		}
+		c.mu.Unlock()
+
+		if err := c.getServerToken(ctx); err != nil {
+			return "", err
+		}
+	}
+}
+
+// getServerToken fetches a new token and adds it to the pool.
+// Firmware without /api/webserver/token gets it from /api/webserver/SesTokInfo instead.
+func (c *RouterClient) getServerToken(ctx context.Context) error {
+	responseData, err := c.do(ctx, "GET", tokenURL, "", nil)
+	if errors.Is(err, ErrNotSupported) {
+		return c.getSessionAndToken(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Token string `xml:"token"`
+	}
+
+	v := Response{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return err
+	}
+
+	token := v.Token
+	if len(token) > 32 {
+		token = token[32:]
+	}
+
+	return c.addToken(token)
+}
+
+func (c *RouterClient) getSessionAndToken(ctx context.Context) error {
+	responseData, err := c.do(ctx, "GET", sesTokInfoURL, "", nil)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		SesInfo string `xml:"SesInfo"`
+		TokInfo string `xml:"TokInfo"`
+	}
+
+	v := Response{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return err
+	}
+
+	if cookie := strings.SplitN(v.SesInfo, "=", 2); len(cookie) == 2 && c.client.Jar != nil {
+		u, err := url.Parse(c.routerURL)
+		if err != nil {
+			return err
+		}
+		c.client.Jar.SetCookies(u, []*http.Cookie{{Name: cookie[0], Value: cookie[1], Path: "/"}})
+	}
+
+	return c.addToken(v.TokInfo)
+}
+
+func (c *RouterClient) addToken(token string) error {
+	if token == "" {
+		return errors.New("router returned empty verification token")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pushToken(token)
+	return nil
+}
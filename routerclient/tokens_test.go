@@ -0,0 +1,231 @@
+package routerclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokensFromHeaderAreConsumedOnePerPost(t *testing.T) {
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/api/device/control":
+			received = append(received, r.Header.Get(requestVerificationToken))
+			if len(received) == 1 {
+				w.Header().Add("__RequestVerificationToken", "token2#token3#")
+			}
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+	client.tokens = []string{"token1"}
+
+	for i := 0; i < 3; i++ {
+		err = client.Reboot()
+		assert.Nil(t, err, "error rebooting: %q", err)
+	}
+
+	// the newest token is used first
+	assert.Equal(t, []string{"token1", "token3", "token2"}, received)
+	assert.Empty(t, client.tokens)
+}
+
+func TestTokensRepeatedInHeadersArePooledOnce(t *testing.T) {
+	client, err := NewRouterClient("http://localhost", "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+	client.tokens = []string{"token1"}
+
+	header := http.Header{}
+	header.Set("__RequestVerificationToken", "token1#token2#token3#")
+	header.Set("__RequestVerificationTokenone", "token2")
+	header.Set("__RequestVerificationTokentwo", "token3")
+	client.updateVerificationTokenFromHeaders(&http.Response{Header: header})
+	assert.Equal(t, []string{"token1", "token2", "token3"}, client.tokens)
+
+	header = http.Header{}
+	header.Set("__RequestVerificationToken", "token3#token4")
+	client.updateVerificationTokenFromHeaders(&http.Response{Header: header})
+	assert.Equal(t, []string{"token1", "token2", "token3", "token4"}, client.tokens)
+}
+
+func TestTokenPoolKeepsNewestTokens(t *testing.T) {
+	client, err := NewRouterClient("http://localhost", "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	for i := 0; i < maxTokens+5; i++ {
+		err = client.addToken(fmt.Sprintf("token%d", i))
+		assert.Nil(t, err, "error adding token: %q", err)
+	}
+
+	assert.Len(t, client.tokens, maxTokens)
+	assert.Equal(t, "token5", client.tokens[0])
+
+	token, err := client.nextToken(context.Background())
+	assert.Nil(t, err, "error taking token: %q", err)
+	assert.Equal(t, fmt.Sprintf("token%d", maxTokens+4), token)
+}
+
+func TestEmptyPoolIsRefilledFromServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvzS7xTD7LGjRhXAtLCQRYWUKc5YdaRuzJJ</token></response>")
+		case "/api/device/control":
+			assert.Equal(t, "S7xTD7LGjRhXAtLCQRYWUKc5YdaRuzJJ", r.Header.Get(requestVerificationToken))
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+}
+
+func TestTokenIsTakenFromSesTokInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>100002</code>\n<message/>\n</error>\n")
+		case "/api/webserver/SesTokInfo":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><SesInfo>SessionID=abcdef</SesInfo><TokInfo>sestoken</TokInfo></response>")
+		case "/api/device/control":
+			assert.Equal(t, "sestoken", r.Header.Get(requestVerificationToken))
+			c, err := r.Cookie("SessionID")
+			assert.Nil(t, err, "session cookie expected: %q", err)
+			if err == nil {
+				assert.Equal(t, "abcdef", c.Value)
+			}
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+}
+
+func TestShortTokensAreAccepted(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/api/webserver/token":
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>a</token></response>")
+		default:
+			w.Header().Add("__RequestVerificationToken", "b")
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.getServerToken(context.Background())
+	assert.Nil(t, err, "error retrieving token: %q", err)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+	assert.Equal(t, []string{"b"}, client.tokens)
+}
+
+func TestConcurrentPostsUseDistinctTokens(t *testing.T) {
+	var mu sync.Mutex
+	issued := 0
+	used := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.RequestURI() {
+		case "/api/webserver/token":
+			issued++
+			fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?><response><token>TJECW4tvlJ2fGiClXMwew8wiGWRKlzvz%032d</token></response>", issued)
+		case "/api/device/control":
+			token := r.Header.Get(requestVerificationToken)
+			if used[token] {
+				t.Errorf("token %s used twice", token)
+			}
+			used[token] = true
+			issued++
+			w.Header().Add("__RequestVerificationToken", fmt.Sprintf("%032d", issued))
+			fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+		default:
+			t.Errorf("wrong URL called %s", r.URL.RequestURI())
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if err := client.Reboot(); err != nil {
+					t.Errorf("error rebooting: %q", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, used, 200)
+}
+
+func TestConcurrentRequestsShareRenewedSession(t *testing.T) {
+	router := &fakeRouter{}
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client, err := NewRouterClient(ts.URL, "admin", "pass")
+	assert.Nil(t, err, "error creating client: %q", err)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	for round := 0; round < 5; round++ {
+		router.expire()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.GetSignalStats(); err != nil {
+					t.Errorf("error getting signal stats: %q", err)
+				}
+				if _, err := client.LoginState(); err != nil {
+					t.Errorf("error getting login state: %q", err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	assert.Equal(t, 100, router.signalCalls)
+	assert.Equal(t, 6, router.logins, "concurrent requests should share renewed session")
+}
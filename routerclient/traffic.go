@@ -0,0 +1,333 @@
+package routerclient
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TrafficStatistics are data counters of the current connection and since they were cleared.
+// Data is in bytes, rates in bytes per second and times in seconds.
+type TrafficStatistics struct {
+	CurrentConnectTime  *int   `json:"CurrentConnectTime"`
+	CurrentUpload       *int64 `json:"CurrentUpload"`
+	CurrentDownload     *int64 `json:"CurrentDownload"`
+	CurrentUploadRate   *int64 `json:"CurrentUploadRate"`
+	CurrentDownloadRate *int64 `json:"CurrentDownloadRate"`
+	TotalConnectTime    *int   `json:"TotalConnectTime"`
+	TotalUpload         *int64 `json:"TotalUpload"`
+	TotalDownload       *int64 `json:"TotalDownload"`
+
+	// Warnings lists values router reported in unexpected format
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+// MonthStatistics is data used in the current billing month, which begins on DataPlan.StartDay.
+// Data is in bytes and times in seconds.
+type MonthStatistics struct {
+	Upload   *int64 `json:"Upload"`
+	Download *int64 `json:"Download"`
+	Duration *int   `json:"Duration"`
+	// LastClearTime is the date counters were cleared last, as reported by router, e.g. 2020-10-1
+	LastClearTime string `json:"LastClearTime"`
+	DayUsed       *int64 `json:"DayUsed"`
+	DayDuration   *int   `json:"DayDuration"`
+
+	// Warnings lists values router reported in unexpected format
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+// Used returns data uploaded and downloaded in the month, nil if router did not report it
+func (m MonthStatistics) Used() *int64 {
+	if m.Upload == nil || m.Download == nil {
+		return nil
+	}
+	used := *m.Upload + *m.Download
+	return &used
+}
+
+// DataPlan is the monthly data limit set in router
+type DataPlan struct {
+	// Enabled turns on monthly statistics and data limit
+	Enabled bool `json:"Enabled"`
+	// StartDay is the day of month billing month begins, 1-31. Router reports 0 before
+	// the plan was set for the first time, GetDataPlan returns 1 then, its default.
+	StartDay int `json:"StartDay"`
+	// DataLimit is the monthly limit in bytes, router accepts whole MB
+	DataLimit int64 `json:"DataLimit"`
+	// Threshold is the percentage of DataLimit router warns at
+	Threshold int `json:"Threshold"`
+
+	// Warnings lists values router reported in unexpected format, DataLimit is 0 if it is one of them
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+const (
+	megabyte = 1 << 20
+	gigabyte = 1 << 30
+)
+
+// Validate checks that router will accept the plan
+func (p DataPlan) Validate() error {
+	if p.StartDay < 1 || p.StartDay > 31 {
+		return fmt.Errorf("start day must be between 1 and 31, got %d", p.StartDay)
+	}
+	if p.DataLimit < 0 || p.DataLimit%megabyte != 0 {
+		return fmt.Errorf("data limit must be a whole number of MB, got %d bytes", p.DataLimit)
+	}
+	if p.Threshold < 0 || p.Threshold > 100 {
+		return fmt.Errorf("threshold must be between 0 and 100, got %d", p.Threshold)
+	}
+	return nil
+}
+
+// Usage returns percentage of the data limit used in the month, nil if there is no limit
+func (p DataPlan) Usage(m MonthStatistics) *float64 {
+	used := m.Used()
+	if !p.Enabled || p.DataLimit <= 0 || used == nil {
+		return nil
+	}
+	usage := float64(*used) / float64(p.DataLimit) * 100
+	return &usage
+}
+
+// ParseDataLimit parses data limit the way router reports it, e.g. "500MB" or "10GB"
+func ParseDataLimit(v string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(v))
+	unit := int64(megabyte)
+	switch {
+	case strings.HasSuffix(s, "GB"):
+		unit, s = gigabyte, strings.TrimSuffix(s, "GB")
+	case strings.HasSuffix(s, "MB"):
+		s = strings.TrimSuffix(s, "MB")
+	default:
+		return 0, fmt.Errorf("invalid data limit %q, expected e.g. 500MB or 10GB", v)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/unit {
+		return 0, fmt.Errorf("invalid data limit %q, expected e.g. 500MB or 10GB", v)
+	}
+
+	return n * unit, nil
+}
+
+// FormatDataLimit formats data limit in bytes the way router expects it
+func FormatDataLimit(limit int64) string {
+	if limit > 0 && limit%gigabyte == 0 {
+		return fmt.Sprintf("%dGB", limit/gigabyte)
+	}
+	return fmt.Sprintf("%dMB", limit/megabyte)
+}
+
+// counter parses data counter, empty value results in nil
+func (p *valueParser) counter(field string, v string) *int64 {
+	s := strings.TrimSpace(v)
+	if s == "" {
+		return nil
+	}
+
+	val, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		p.warn(field, v)
+		return nil
+	}
+
+	return &val
+}
+
+// GetTrafficStatistics connects to router and fetches traffic statistics
+func (c *RouterClient) GetTrafficStatistics() (TrafficStatistics, error) {
+	return c.GetTrafficStatisticsContext(context.Background())
+}
+
+// GetTrafficStatisticsContext is like GetTrafficStatistics, but the request is bound to ctx
+func (c *RouterClient) GetTrafficStatisticsContext(ctx context.Context) (TrafficStatistics, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", trafficStatisticsURL, "", nil)
+	if err != nil {
+		return TrafficStatistics{}, err
+	}
+
+	v := struct {
+		CurrentConnectTime  string `xml:"CurrentConnectTime"`
+		CurrentUpload       string `xml:"CurrentUpload"`
+		CurrentDownload     string `xml:"CurrentDownload"`
+		CurrentUploadRate   string `xml:"CurrentUploadRate"`
+		CurrentDownloadRate string `xml:"CurrentDownloadRate"`
+		TotalConnectTime    string `xml:"TotalConnectTime"`
+		TotalUpload         string `xml:"TotalUpload"`
+		TotalDownload       string `xml:"TotalDownload"`
+	}{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return TrafficStatistics{}, err
+	}
+
+	p := &valueParser{}
+	return TrafficStatistics{
+		CurrentConnectTime:  p.integer("CurrentConnectTime", v.CurrentConnectTime),
+		CurrentUpload:       p.counter("CurrentUpload", v.CurrentUpload),
+		CurrentDownload:     p.counter("CurrentDownload", v.CurrentDownload),
+		CurrentUploadRate:   p.counter("CurrentUploadRate", v.CurrentUploadRate),
+		CurrentDownloadRate: p.counter("CurrentDownloadRate", v.CurrentDownloadRate),
+		TotalConnectTime:    p.integer("TotalConnectTime", v.TotalConnectTime),
+		TotalUpload:         p.counter("TotalUpload", v.TotalUpload),
+		TotalDownload:       p.counter("TotalDownload", v.TotalDownload),
+		Warnings:            p.warnings,
+	}, nil
+}
+
+// GetMonthStatistics connects to router and fetches data used in the current billing month
+func (c *RouterClient) GetMonthStatistics() (MonthStatistics, error) {
+	return c.GetMonthStatisticsContext(context.Background())
+}
+
+// GetMonthStatisticsContext is like GetMonthStatistics, but the request is bound to ctx
+func (c *RouterClient) GetMonthStatisticsContext(ctx context.Context) (MonthStatistics, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", monthStatisticsURL, "", nil)
+	if err != nil {
+		return MonthStatistics{}, err
+	}
+
+	v := struct {
+		CurrentMonthUpload   string `xml:"CurrentMonthUpload"`
+		CurrentMonthDownload string `xml:"CurrentMonthDownload"`
+		MonthDuration        string `xml:"MonthDuration"`
+		MonthLastClearTime   string `xml:"MonthLastClearTime"`
+		CurrentDayUsed       string `xml:"CurrentDayUsed"`
+		CurrentDayDuration   string `xml:"CurrentDayDuration"`
+	}{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return MonthStatistics{}, err
+	}
+
+	p := &valueParser{}
+	return MonthStatistics{
+		Upload:        p.counter("CurrentMonthUpload", v.CurrentMonthUpload),
+		Download:      p.counter("CurrentMonthDownload", v.CurrentMonthDownload),
+		Duration:      p.integer("MonthDuration", v.MonthDuration),
+		LastClearTime: strings.TrimSpace(v.MonthLastClearTime),
+		DayUsed:       p.counter("CurrentDayUsed", v.CurrentDayUsed),
+		DayDuration:   p.integer("CurrentDayDuration", v.CurrentDayDuration),
+		Warnings:      p.warnings,
+	}, nil
+}
+
+// GetDataPlan connects to router and fetches the monthly data plan
+func (c *RouterClient) GetDataPlan() (DataPlan, error) {
+	return c.GetDataPlanContext(context.Background())
+}
+
+// GetDataPlanContext is like GetDataPlan, but the request is bound to ctx
+func (c *RouterClient) GetDataPlanContext(ctx context.Context) (DataPlan, error) {
+	responseData, err := c.doWithRelogin(ctx, "GET", startDateURL, "", nil)
+	if err != nil {
+		return DataPlan{}, err
+	}
+
+	v := struct {
+		StartDay       int    `xml:"StartDay"`
+		DataLimit      string `xml:"DataLimit"`
+		MonthThreshold int    `xml:"MonthThreshold"`
+		SetMonthData   int    `xml:"SetMonthData"`
+	}{}
+	err = xml.Unmarshal(responseData, &v)
+	if err != nil {
+		return DataPlan{}, err
+	}
+
+	// limit is not reported when it was never set
+	p := &valueParser{}
+	var limit int64
+	if strings.TrimSpace(v.DataLimit) != "" {
+		limit, err = ParseDataLimit(v.DataLimit)
+		if err != nil {
+			limit = 0
+			p.warn("DataLimit", v.DataLimit)
+		}
+	}
+
+	// start day is not set until the plan is, router then uses the first day of month
+	startDay := v.StartDay
+	if startDay == 0 {
+		startDay = 1
+	}
+
+	return DataPlan{
+		Enabled:   v.SetMonthData == 1,
+		StartDay:  startDay,
+		DataLimit: limit,
+		Threshold: v.MonthThreshold,
+		Warnings:  p.warnings,
+	}, nil
+}
+
+// SetDataPlan connects to router and sets the monthly data plan
+func (c *RouterClient) SetDataPlan(plan DataPlan) error {
+	return c.SetDataPlanContext(context.Background(), plan)
+}
+
+// SetDataPlanContext is like SetDataPlan, but the request is bound to ctx
+func (c *RouterClient) SetDataPlanContext(ctx context.Context, plan DataPlan) error {
+	err := plan.Validate()
+	if err != nil {
+		return err
+	}
+
+	type StartDateRequest struct {
+		XMLName        xml.Name `xml:"request"`
+		StartDay       int      `xml:"StartDay"`
+		DataLimit      string   `xml:"DataLimit"`
+		MonthThreshold int      `xml:"MonthThreshold"`
+		SetMonthData   int      `xml:"SetMonthData"`
+	}
+
+	request := StartDateRequest{
+		StartDay:       plan.StartDay,
+		DataLimit:      FormatDataLimit(plan.DataLimit),
+		MonthThreshold: plan.Threshold,
+	}
+	if plan.Enabled {
+		request.SetMonthData = 1
+	}
+
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.doWithRelogin(ctx, "POST", startDateURL, formContentType, body)
+	if err != nil {
+		return fmt.Errorf("error setting data plan: %w", err)
+	}
+
+	return nil
+}
+
+// ClearTraffic connects to router and resets traffic statistics
+func (c *RouterClient) ClearTraffic() error {
+	return c.ClearTrafficContext(context.Background())
+}
+
+// ClearTrafficContext is like ClearTraffic, but the request is bound to ctx
+func (c *RouterClient) ClearTrafficContext(ctx context.Context) error {
+	type ClearTrafficRequest struct {
+		XMLName      xml.Name `xml:"request"`
+		ClearTraffic int      `xml:"ClearTraffic"`
+	}
+
+	body, err := xml.Marshal(ClearTrafficRequest{ClearTraffic: 1})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.doWithRelogin(ctx, "POST", clearTrafficURL, formContentType, body)
+	if err != nil {
+		return fmt.Errorf("error clearing traffic statistics: %w", err)
+	}
+
+	return nil
+}
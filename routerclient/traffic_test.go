@@ -0,0 +1,199 @@
+package routerclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestCanGetTrafficStatistics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/monitoring/traffic-statistics", r.URL.RequestURI())
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<CurrentConnectTime>3600</CurrentConnectTime>\n<CurrentUpload>52428800</CurrentUpload>\n<CurrentDownload>5368709120</CurrentDownload>\n<CurrentDownloadRate>1250000</CurrentDownloadRate>\n<CurrentUploadRate>125000</CurrentUploadRate>\n<TotalUpload>1073741824</TotalUpload>\n<TotalDownload>107374182400</TotalDownload>\n<TotalConnectTime>2592000</TotalConnectTime>\n<showtraffic>1</showtraffic>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	stats, err := client.GetTrafficStatistics()
+
+	assert.Nil(t, err, "error getting traffic statistics: %q", err)
+	assert.Equal(t, TrafficStatistics{
+		CurrentConnectTime:  intPtr(3600),
+		CurrentUpload:       int64Ptr(52428800),
+		CurrentDownload:     int64Ptr(5368709120),
+		CurrentUploadRate:   int64Ptr(125000),
+		CurrentDownloadRate: int64Ptr(1250000),
+		TotalConnectTime:    intPtr(2592000),
+		TotalUpload:         int64Ptr(1073741824),
+		TotalDownload:       int64Ptr(107374182400),
+	}, stats)
+}
+
+func TestCanGetMonthStatistics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/monitoring/month_statistics", r.URL.RequestURI())
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<CurrentMonthDownload>8589934592</CurrentMonthDownload>\n<CurrentMonthUpload>1073741824</CurrentMonthUpload>\n<MonthDuration>864000</MonthDuration>\n<MonthLastClearTime>2020-10-1</MonthLastClearTime>\n<CurrentDayUsed>104857600</CurrentDayUsed>\n<CurrentDayDuration>abc</CurrentDayDuration>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	stats, err := client.GetMonthStatistics()
+
+	assert.Nil(t, err, "error getting month statistics: %q", err)
+	assert.Equal(t, MonthStatistics{
+		Upload:        int64Ptr(1073741824),
+		Download:      int64Ptr(8589934592),
+		Duration:      intPtr(864000),
+		LastClearTime: "2020-10-1",
+		DayUsed:       int64Ptr(104857600),
+		Warnings:      []string{`CurrentDayDuration: unexpected value "abc"`},
+	}, stats)
+	assert.Equal(t, int64Ptr(9663676416), stats.Used())
+}
+
+func TestCanGetDataPlan(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/monitoring/start_date", r.URL.RequestURI())
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<StartDay>15</StartDay>\n<DataLimit>20GB</DataLimit>\n<DataLimitAwoke>0</DataLimitAwoke>\n<MonthThreshold>90</MonthThreshold>\n<SetMonthData>1</SetMonthData>\n<trafficmaxlimit>0</trafficmaxlimit>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	plan, err := client.GetDataPlan()
+
+	assert.Nil(t, err, "error getting data plan: %q", err)
+	assert.Equal(t, DataPlan{Enabled: true, StartDay: 15, DataLimit: 20 << 30, Threshold: 90}, plan)
+}
+
+func TestDataPlanNeverSetStartsOnFirstDay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<StartDay>0</StartDay>\n<DataLimit></DataLimit>\n<MonthThreshold>0</MonthThreshold>\n<SetMonthData>0</SetMonthData>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	plan, err := client.GetDataPlan()
+
+	assert.Nil(t, err, "error getting data plan: %q", err)
+	assert.Equal(t, DataPlan{StartDay: 1}, plan)
+	assert.Nil(t, plan.Validate(), "plan read from router should be valid")
+}
+
+func TestDataPlanWithMalformedLimitIsReturnedWithWarning(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<StartDay>15</StartDay>\n<DataLimit>lots</DataLimit>\n<MonthThreshold>90</MonthThreshold>\n<SetMonthData>1</SetMonthData>\n</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	plan, err := client.GetDataPlan()
+
+	assert.Nil(t, err, "error getting data plan: %q", err)
+	assert.Equal(t, DataPlan{
+		Enabled:   true,
+		StartDay:  15,
+		Threshold: 90,
+		Warnings:  []string{`DataLimit: unexpected value "lots"`},
+	}, plan)
+}
+
+func TestCanSetDataPlan(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/monitoring/start_date", r.URL.Path)
+		assert.Equal(t, "<request><StartDay>1</StartDay><DataLimit>500MB</DataLimit><MonthThreshold>80</MonthThreshold><SetMonthData>1</SetMonthData></request>", string(body))
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	client.tokens = []string{"token1"}
+
+	err = client.SetDataPlan(DataPlan{Enabled: true, StartDay: 1, DataLimit: 500 << 20, Threshold: 80})
+	assert.Nil(t, err, "error setting data plan: %q", err)
+
+	err = client.SetDataPlan(DataPlan{Enabled: true, StartDay: 32, DataLimit: 500 << 20})
+	assert.EqualError(t, err, "start day must be between 1 and 31, got 32")
+}
+
+func TestCanClearTraffic(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/monitoring/clear-traffic", r.URL.Path)
+		assert.Equal(t, "<request><ClearTraffic>1</ClearTraffic></request>", string(body))
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>\n<code>100002</code>\n<message></message>\n</error>\n")
+	}))
+	defer ts.Close()
+	client, err := NewRouterClient(ts.URL, "user", "pass")
+	assert.Nil(t, err, "error creating RouterClient: %q", err)
+
+	client.tokens = []string{"token1"}
+
+	err = client.ClearTraffic()
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.Contains(t, err.Error(), "error clearing traffic statistics")
+}
+
+func TestDataLimit(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{"500MB", 500 << 20},
+		{"10GB", 10 << 30},
+		{"0MB", 0},
+		{" 1gb ", 1 << 30},
+	}
+
+	for _, tt := range tests {
+		limit, err := ParseDataLimit(tt.value)
+		assert.Nil(t, err, "error parsing %q: %q", tt.value, err)
+		assert.Equal(t, tt.expected, limit)
+	}
+
+	for _, v := range []string{"", "10", "10TB", "-1GB", "GB", "99999999999999GB"} {
+		_, err := ParseDataLimit(v)
+		assert.NotNil(t, err, "%q should not be accepted", v)
+	}
+
+	assert.Equal(t, "10GB", FormatDataLimit(10<<30))
+	assert.Equal(t, "1536MB", FormatDataLimit(1536<<20))
+	assert.Equal(t, "0MB", FormatDataLimit(0))
+}
+
+func TestDataPlanUsage(t *testing.T) {
+	month := MonthStatistics{Upload: int64Ptr(1 << 30), Download: int64Ptr(4 << 30)}
+
+	assert.Equal(t, floatPtr(50), DataPlan{Enabled: true, StartDay: 1, DataLimit: 10 << 30}.Usage(month))
+	assert.Nil(t, DataPlan{StartDay: 1, DataLimit: 10 << 30}.Usage(month), "disabled plan has no usage")
+	assert.Nil(t, DataPlan{Enabled: true, StartDay: 1}.Usage(month), "plan without limit has no usage")
+	assert.Nil(t, DataPlan{Enabled: true, StartDay: 1, DataLimit: 10 << 30}.Usage(MonthStatistics{}))
+}
+
+func TestDataPlanValidation(t *testing.T) {
+	assert.Nil(t, DataPlan{StartDay: 31, DataLimit: 1 << 20, Threshold: 100}.Validate())
+	assert.EqualError(t, DataPlan{StartDay: 0}.Validate(), "start day must be between 1 and 31, got 0")
+	assert.EqualError(t, DataPlan{StartDay: 1, DataLimit: 1000}.Validate(), "data limit must be a whole number of MB, got 1000 bytes")
+	assert.EqualError(t, DataPlan{StartDay: 1, Threshold: 101}.Validate(), "threshold must be between 0 and 100, got 101")
+}
@@ -0,0 +1,96 @@
+package routeremu
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// Device is the device information reported by the emulated router
+type Device struct {
+	DeviceName      string
+	SerialNumber    string
+	IMEI            string
+	IMSI            string
+	ICCID           string
+	HardwareVersion string
+	SoftwareVersion string
+	WebUIVersion    string
+	MACAddress      string
+	WANIPAddress    string
+}
+
+// DefaultDevice is a B618 with made up identifiers
+var DefaultDevice = Device{
+	DeviceName:      "B618s-22d",
+	SerialNumber:    "G4Q7S18A12345678",
+	IMEI:            "861234567890123",
+	IMSI:            "260031234567890",
+	ICCID:           "8948031234567890123",
+	HardwareVersion: "WL1B610FM",
+	SoftwareVersion: "11.0.2.1(H183SP1C983)",
+	WebUIVersion:    "WEBUI 11.0.2.1(W1SP7C983)",
+	MACAddress:      "E4:A7:C5:11:22:33",
+	WANIPAddress:    "10.123.45.67",
+}
+
+func (e *Emulator) handleDeviceInformation(s *session, w http.ResponseWriter, body []byte) {
+	dev := e.cfg.Device
+	writeResponse(w, struct {
+		XMLName         xml.Name `xml:"response"`
+		DeviceName      string   `xml:"DeviceName"`
+		SerialNumber    string   `xml:"SerialNumber"`
+		IMEI            string   `xml:"Imei"`
+		IMSI            string   `xml:"Imsi"`
+		ICCID           string   `xml:"Iccid"`
+		MSISDN          string   `xml:"Msisdn"`
+		HardwareVersion string   `xml:"HardwareVersion"`
+		SoftwareVersion string   `xml:"SoftwareVersion"`
+		WebUIVersion    string   `xml:"WebUIVersion"`
+		MACAddress1     string   `xml:"MacAddress1"`
+		MACAddress2     string   `xml:"MacAddress2"`
+		WANIPAddress    string   `xml:"WanIPAddress"`
+		WANIPv6Address  string   `xml:"WanIPv6Address"`
+		ProductFamily   string   `xml:"ProductFamily"`
+		Classify        string   `xml:"Classify"`
+		SupportMode     string   `xml:"supportmode"`
+		WorkMode        string   `xml:"workmode"`
+		MCCMNC          string   `xml:"Mccmnc"`
+		Uptime          int      `xml:"uptime"`
+	}{
+		DeviceName:      dev.DeviceName,
+		SerialNumber:    dev.SerialNumber,
+		IMEI:            dev.IMEI,
+		IMSI:            dev.IMSI,
+		ICCID:           dev.ICCID,
+		HardwareVersion: dev.HardwareVersion,
+		SoftwareVersion: dev.SoftwareVersion,
+		WebUIVersion:    dev.WebUIVersion,
+		MACAddress1:     dev.MACAddress,
+		WANIPAddress:    dev.WANIPAddress,
+		ProductFamily:   "LTE",
+		Classify:        "cpe",
+		SupportMode:     "LTE|WCDMA|GSM",
+		WorkMode:        "LTE",
+		MCCMNC:          e.signal.PLMN,
+		Uptime:          int(e.now().Sub(e.bootedAt).Seconds()),
+	})
+}
+
+func (e *Emulator) handleBasicInformation(s *session, w http.ResponseWriter, body []byte) {
+	dev := e.cfg.Device
+	writeResponse(w, struct {
+		XMLName         xml.Name `xml:"response"`
+		ProductFamily   string   `xml:"productfamily"`
+		Classify        string   `xml:"classify"`
+		MultiMode       int      `xml:"multimode"`
+		DeviceName      string   `xml:"devicename"`
+		SoftwareVersion string   `xml:"SoftwareVersion"`
+		WebUIVersion    string   `xml:"WebUIVersion"`
+	}{
+		ProductFamily:   "LTE",
+		Classify:        "cpe",
+		DeviceName:      dev.DeviceName,
+		SoftwareVersion: dev.SoftwareVersion,
+		WebUIVersion:    dev.WebUIVersion,
+	})
+}
@@ -0,0 +1,232 @@
+package routeremu
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type routeKey struct {
+	method string
+	path   string
+}
+
+type route struct {
+	// auth routes are available only to logged in sessions
+	auth    bool
+	handler func(e *Emulator, s *session, w http.ResponseWriter, body []byte)
+}
+
+var routes = map[routeKey]route{
+	{"GET", "/"}:                                  {handler: (*Emulator).handleIndex},
+	{"GET", "/api/webserver/token"}:               {handler: (*Emulator).handleToken},
+	{"GET", "/api/webserver/SesTokInfo"}:          {handler: (*Emulator).handleSesTokInfo},
+	{"GET", "/api/user/state-login"}:              {handler: (*Emulator).handleStateLogin},
+	{"POST", "/api/user/challenge_login"}:         {handler: (*Emulator).handleChallengeLogin},
+	{"POST", "/api/user/authentication_login"}:    {handler: (*Emulator).handleAuthLogin},
+	{"POST", "/api/user/logout"}:                  {auth: true, handler: (*Emulator).handleLogout},
+	{"GET", "/api/device/signal"}:                 {auth: true, handler: (*Emulator).handleSignal},
+	{"GET", "/api/device/information"}:            {auth: true, handler: (*Emulator).handleDeviceInformation},
+	{"GET", "/api/device/basic_information"}:      {handler: (*Emulator).handleBasicInformation},
+	{"GET", "/api/monitoring/status"}:             {auth: true, handler: (*Emulator).handleStatus},
+	{"GET", "/api/monitoring/traffic-statistics"}: {auth: true, handler: (*Emulator).handleTrafficStatistics},
+	{"GET", "/api/monitoring/month_statistics"}:   {auth: true, handler: (*Emulator).handleMonthStatistics},
+	{"GET", "/api/monitoring/start_date"}:         {auth: true, handler: (*Emulator).handleGetStartDate},
+	{"POST", "/api/monitoring/start_date"}:        {auth: true, handler: (*Emulator).handleSetStartDate},
+	{"POST", "/api/monitoring/clear-traffic"}:     {auth: true, handler: (*Emulator).handleClearTraffic},
+	{"POST", "/api/device/control"}:               {auth: true, handler: (*Emulator).handleControl},
+}
+
+func (e *Emulator) handleIndex(s *session, w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, "<!DOCTYPE html><html><head><title>LTE CPE B618</title></head><body></body></html>\n")
+}
+
+func (e *Emulator) handleToken(s *session, w http.ResponseWriter, body []byte) {
+	// router prefixes the token with 32 characters the client strips
+	writeResponse(w, struct {
+		XMLName xml.Name `xml:"response"`
+		Token   string   `xml:"token"`
+	}{Token: randomHex(32) + s.newToken()})
+}
+
+func (e *Emulator) handleSesTokInfo(s *session, w http.ResponseWriter, body []byte) {
+	var id string
+	for sid, ses := range e.sessions {
+		if ses == s {
+			id = sid
+		}
+	}
+
+	writeResponse(w, struct {
+		XMLName xml.Name `xml:"response"`
+		SesInfo string   `xml:"SesInfo"`
+		TokInfo string   `xml:"TokInfo"`
+	}{SesInfo: sessionCookie + "=" + id, TokInfo: s.newToken()})
+}
+
+// lockedFor returns the remaining lockout time, zero when login is not locked
+func (e *Emulator) lockedFor() time.Duration {
+	wait := e.lockedUntil.Sub(e.now())
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func (e *Emulator) handleStateLogin(s *session, w http.ResponseWriter, body []byte) {
+	state, lockStatus := -1, 0
+	if s.loggedIn {
+		state = 0
+	}
+	wait := e.lockedFor()
+	if wait > 0 {
+		lockStatus = 1
+	}
+
+	writeResponse(w, struct {
+		XMLName            xml.Name `xml:"response"`
+		State              int      `xml:"State"`
+		Username           string   `xml:"Username"`
+		PasswordType       int      `xml:"password_type"`
+		ExternPasswordType int      `xml:"extern_password_type"`
+		FirstLogin         int      `xml:"firstlogin"`
+		RemainWaitTime     int      `xml:"remain_wait_time"`
+		LockStatus         int      `xml:"lockstatus"`
+		AccountsNumber     int      `xml:"accounts_number"`
+		RSAPaddingType     int      `xml:"rsapadingtype"`
+	}{
+		State:              state,
+		Username:           e.cfg.Username,
+		PasswordType:       4,
+		ExternPasswordType: 1,
+		FirstLogin:         1,
+		RemainWaitTime:     int((wait + time.Second - 1) / time.Second),
+		LockStatus:         lockStatus,
+		AccountsNumber:     1,
+		RSAPaddingType:     1,
+	})
+}
+
+// writeLockout reports locked login, wait time is given in minutes
+func (e *Emulator) writeLockout(w http.ResponseWriter) {
+	minutes := int((e.lockedFor() + time.Minute - 1) / time.Minute)
+	writeError(w, ErrorTooManyAttempts, map[string]string{"waittime": strconv.Itoa(minutes)})
+}
+
+func (e *Emulator) handleChallengeLogin(s *session, w http.ResponseWriter, body []byte) {
+	if e.lockedFor() > 0 {
+		e.writeLockout(w)
+		return
+	}
+
+	v := struct {
+		Username   string `xml:"username"`
+		Firstnonce string `xml:"firstnonce"`
+		Mode       int    `xml:"mode"`
+	}{}
+	if err := xml.Unmarshal(body, &v); err != nil || v.Firstnonce == "" || v.Mode != 1 {
+		writeError(w, ErrorParameter, nil)
+		return
+	}
+
+	if v.Username != e.cfg.Username {
+		writeError(w, ErrorUsername, nil)
+		return
+	}
+
+	s.clientNonce = v.Firstnonce
+	s.serverNonce = v.Firstnonce + randomHex(32)
+
+	writeResponse(w, struct {
+		XMLName      xml.Name `xml:"response"`
+		Iterations   int      `xml:"iterations"`
+		ServerNonce  string   `xml:"servernonce"`
+		ModeSelected int      `xml:"modeselected"`
+		Salt         string   `xml:"salt"`
+		NewType      int      `xml:"newType"`
+	}{
+		Iterations:   e.cfg.Iterations,
+		ServerNonce:  s.serverNonce,
+		ModeSelected: 1,
+		Salt:         e.salt,
+	})
+}
+
+func (e *Emulator) handleAuthLogin(s *session, w http.ResponseWriter, body []byte) {
+	if e.lockedFor() > 0 {
+		e.writeLockout(w)
+		return
+	}
+
+	v := struct {
+		ClientProof string `xml:"clientproof"`
+		FinalNonce  string `xml:"finalnonce"`
+	}{}
+	if err := xml.Unmarshal(body, &v); err != nil || s.serverNonce == "" || v.FinalNonce != s.serverNonce {
+		writeError(w, ErrorParameter, nil)
+		return
+	}
+
+	clientNonce, serverNonce := s.clientNonce, s.serverNonce
+	// challenge can be answered only once
+	s.clientNonce, s.serverNonce = "", ""
+
+	if !e.verifyClientProof(v.ClientProof, clientNonce, serverNonce) {
+		e.failedLogins++
+		e.attempts++
+		remaining := e.cfg.MaxLoginAttempts - e.attempts
+		if remaining <= 0 {
+			e.attempts = 0
+			e.lockedUntil = e.now().Add(e.cfg.LockoutDuration)
+			e.writeLockout(w)
+			return
+		}
+
+		writeError(w, ErrorWrongPassword, map[string]string{"count": strconv.Itoa(remaining)})
+		return
+	}
+
+	e.attempts = 0
+	e.logins++
+	s.loggedIn = true
+
+	rsan, rsae := e.publicKey()
+	// like the firmware, tokens are repeated in the plain header
+	one, two := s.newToken(), s.newToken()
+	w.Header().Set(requestVerificationToken, one+"#"+two+"#")
+	w.Header().Set(requestVerificationToken+"one", one)
+	w.Header().Set(requestVerificationToken+"two", two)
+	writeResponse(w, struct {
+		XMLName            xml.Name `xml:"response"`
+		ServerSignature    string   `xml:"serversignature"`
+		RSAPubKeySignature string   `xml:"rsapubkeysignature"`
+		RSAN               string   `xml:"rsan"`
+		RSAE               string   `xml:"rsae"`
+	}{
+		ServerSignature:    e.serverSignature(clientNonce, serverNonce),
+		RSAPubKeySignature: e.publicKeySignature(),
+		RSAN:               rsan,
+		RSAE:               rsae,
+	})
+}
+
+func (e *Emulator) handleLogout(s *session, w http.ResponseWriter, body []byte) {
+	s.loggedIn = false
+	writeOK(w)
+}
+
+func (e *Emulator) handleControl(s *session, w http.ResponseWriter, body []byte) {
+	v := struct {
+		Control int `xml:"Control"`
+	}{}
+	if err := xml.Unmarshal(body, &v); err != nil || v.Control != 1 {
+		writeError(w, ErrorParameter, nil)
+		return
+	}
+
+	writeOK(w)
+	e.reboot()
+}
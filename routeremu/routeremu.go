@@ -0,0 +1,371 @@
+// Package routeremu implements a stateful emulator of Huawei B618 HiLink web API,
+// for developing and testing tools without a physical router.
+package routeremu
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	mathrand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCookie            = "SessionID"
+	requestVerificationToken = "__RequestVerificationToken"
+
+	// maxTokens is the number of verification tokens kept valid for a session
+	maxTokens = 32
+)
+
+// HiLink error codes returned by the emulator
+const (
+	ErrorNotSupported    = 100002
+	ErrorNoRights        = 100003
+	ErrorParameter       = 100005
+	ErrorUsername        = 108001
+	ErrorWrongPassword   = 108006
+	ErrorTooManyAttempts = 108007
+	ErrorWrongToken      = 125002
+)
+
+// Config describes the emulated router. Zero values are replaced with defaults.
+type Config struct {
+	// Username and Password of the admin account, default admin/admin
+	Username string
+	Password string
+	// Iterations of PBKDF2 used in SCRAM login, default 100
+	Iterations int
+	// RSAKeyBits is size of the key used for encrypted requests, default 2048
+	RSAKeyBits int
+
+	// Device is the device information reported by router, default DefaultDevice
+	Device *Device
+
+	// Signal is the initial signal reported by router, default DefaultSignal
+	Signal *Signal
+	// RandomWalk makes signal values change slightly with every request
+	RandomWalk bool
+	// Seed of the random walk, default is based on current time
+	Seed int64
+
+	// RebootDuration is the time router is offline after reboot, default 1 minute
+	RebootDuration time.Duration
+	// SessionTimeout drops sessions idle for longer, default 5 minutes
+	SessionTimeout time.Duration
+	// MaxLoginAttempts is the number of failed logins before the login is locked, default 3
+	MaxLoginAttempts int
+	// LockoutDuration is the time login stays locked, default 1 minute
+	LockoutDuration time.Duration
+}
+
+// session is the state of a single router web session
+type session struct {
+	loggedIn    bool
+	tokens      []string
+	lastSeen    time.Time
+	clientNonce string
+	serverNonce string
+}
+
+// Emulator is a http.Handler emulating the router web API
+type Emulator struct {
+	cfg       Config
+	salt      string
+	storedKey []byte
+	serverKey []byte
+	key       *rsa.PrivateKey
+
+	mu           sync.Mutex
+	now          func() time.Time
+	rand         *mathrand.Rand
+	signal       Signal
+	sessions     map[string]*session
+	offlineUntil time.Time
+	// bootedAt is the time router started, uptime is counted from it
+	bootedAt time.Time
+	// disconnected is set when mobile data connection is down
+	disconnected bool
+	// startedAt is the time emulator started, traffic is counted from it or from clearedAt
+	startedAt time.Time
+	clearedAt time.Time
+	plan      dataPlan
+	// attempts counts failed logins since the last successful one
+	attempts     int
+	failedLogins int
+	lockedUntil  time.Time
+	logins       int
+	reboots      int
+}
+
+// New creates emulator with given configuration
+func New(cfg Config) (*Emulator, error) {
+	if cfg.Username == "" {
+		cfg.Username = "admin"
+	}
+	if cfg.Password == "" {
+		cfg.Password = "admin"
+	}
+	if cfg.Iterations <= 0 {
+		cfg.Iterations = 100
+	}
+	if cfg.RSAKeyBits <= 0 {
+		cfg.RSAKeyBits = 2048
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	if cfg.RebootDuration <= 0 {
+		cfg.RebootDuration = time.Minute
+	}
+	if cfg.SessionTimeout <= 0 {
+		cfg.SessionTimeout = 5 * time.Minute
+	}
+	if cfg.MaxLoginAttempts <= 0 {
+		cfg.MaxLoginAttempts = 3
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = time.Minute
+	}
+
+	if cfg.Device == nil {
+		device := DefaultDevice
+		cfg.Device = &device
+	}
+
+	signal := DefaultSignal
+	if cfg.Signal != nil {
+		signal = *cfg.Signal
+	}
+
+	e := &Emulator{
+		cfg:      cfg,
+		now:      time.Now,
+		rand:     mathrand.New(mathrand.NewSource(cfg.Seed)),
+		signal:   signal,
+		sessions: map[string]*session{},
+		plan:     defaultDataPlan,
+	}
+	e.startedAt = e.now()
+	e.bootedAt = e.startedAt
+
+	var err error
+	e.salt = randomHex(32)
+	e.storedKey, e.serverKey, err = scramKeys(cfg.Password, e.salt, cfg.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	e.key, err = rsa.GenerateKey(rand.Reader, cfg.RSAKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("error generating RSA key: %w", err)
+	}
+
+	return e, nil
+}
+
+// Signal returns the signal currently reported by router
+func (e *Emulator) Signal() Signal {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.signal
+}
+
+// SetSignal changes the signal reported by router
+func (e *Emulator) SetSignal(s Signal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.signal = s
+}
+
+// Reboot takes router offline for the configured reboot duration, dropping all sessions
+func (e *Emulator) Reboot() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reboot()
+}
+
+func (e *Emulator) reboot() {
+	e.reboots++
+	e.sessions = map[string]*session{}
+	e.offlineUntil = e.now().Add(e.cfg.RebootDuration)
+	e.bootedAt = e.offlineUntil
+}
+
+// Online reports whether router is not rebooting
+func (e *Emulator) Online() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.now().Before(e.offlineUntil)
+}
+
+// ExpireSessions drops all sessions, as if they were idle for too long
+func (e *Emulator) ExpireSessions() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sessions = map[string]*session{}
+}
+
+// Stats are counters of events handled by the emulator
+type Stats struct {
+	Logins       int
+	FailedLogins int
+	Reboots      int
+	Sessions     int
+}
+
+// Stats returns counters of events handled so far
+func (e *Emulator) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return Stats{Logins: e.logins, FailedLogins: e.failedLogins, Reboots: e.reboots, Sessions: len(e.sessions)}
+}
+
+// ServeHTTP handles a request to router web API
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.now().Before(e.offlineUntil) {
+		dropConnection(w)
+		return
+	}
+
+	s := e.session(w, r)
+
+	if r.Method == "POST" {
+		if !s.useToken(r.Header.Get(requestVerificationToken)) {
+			writeError(w, ErrorWrongToken, nil)
+			return
+		}
+		w.Header().Set(requestVerificationToken, s.newToken())
+
+		if strings.HasSuffix(r.Header.Get("Content-Type"), ";enc") {
+			body, err = e.decrypt(body)
+			if err != nil {
+				writeError(w, ErrorParameter, nil)
+				return
+			}
+		}
+	}
+
+	route, ok := routes[routeKey{r.Method, r.URL.Path}]
+	if !ok {
+		writeError(w, ErrorNotSupported, nil)
+		return
+	}
+
+	if route.auth && !s.loggedIn {
+		writeError(w, ErrorNoRights, nil)
+		return
+	}
+
+	route.handler(e, s, w, body)
+}
+
+// session returns session of the request, starting a new one when there is no valid session cookie
+func (e *Emulator) session(w http.ResponseWriter, r *http.Request) *session {
+	now := e.now()
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		s, ok := e.sessions[c.Value]
+		if ok && now.Sub(s.lastSeen) <= e.cfg.SessionTimeout {
+			s.lastSeen = now
+			return s
+		}
+		delete(e.sessions, c.Value)
+	}
+
+	for id, s := range e.sessions {
+		if now.Sub(s.lastSeen) > e.cfg.SessionTimeout {
+			delete(e.sessions, id)
+		}
+	}
+
+	id := randomHex(64)
+	s := &session{lastSeen: now}
+	e.sessions[id] = s
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", HttpOnly: true})
+
+	return s
+}
+
+// newToken adds a new verification token to the session, dropping the oldest ones
+func (s *session) newToken() string {
+	token := randomHex(16)
+	s.tokens = append(s.tokens, token)
+	if len(s.tokens) > maxTokens {
+		s.tokens = s.tokens[len(s.tokens)-maxTokens:]
+	}
+
+	return token
+}
+
+// useToken consumes verification token, each token can be used once
+func (s *session) useToken(token string) bool {
+	for i, t := range s.tokens {
+		if t == token {
+			s.tokens = append(s.tokens[:i], s.tokens[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropConnection closes the connection without response, as unreachable router would
+func dropConnection(w http.ResponseWriter) {
+	if hj, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hj.Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func writeResponse(w http.ResponseWriter, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, "%s<response>OK</response>\n", xml.Header)
+}
+
+// writeError writes HiLink error envelope, details are added as extra elements
+func writeError(w http.ResponseWriter, code int, details map[string]string) {
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, "%s<error>\n<code>%d</code>\n<message></message>\n", xml.Header, code)
+	for name, value := range details {
+		fmt.Fprintf(w, "<%s>%s</%s>\n", name, value, name)
+	}
+	fmt.Fprint(w, "</error>\n")
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n/2)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,438 @@
+package routeremu
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkorz/b618reboot-go/routerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a manually advanced time source
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newEmulator(t *testing.T, cfg Config) (*Emulator, *clock, *httptest.Server) {
+	cfg.RSAKeyBits = 1024
+	e, err := New(cfg)
+	assert.Nil(t, err, "error creating emulator: %q", err)
+
+	c := &clock{now: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+	e.now = c.Now
+	e.startedAt = c.Now()
+	e.bootedAt = e.startedAt
+
+	ts := httptest.NewServer(e)
+	t.Cleanup(ts.Close)
+
+	return e, c, ts
+}
+
+func newClient(t *testing.T, url string, password string) *routerclient.RouterClient {
+	client, err := routerclient.NewRouterClient(url, "admin", password)
+	assert.Nil(t, err, "error creating client: %q", err)
+	return client
+}
+
+func TestClientCanLogInAndGetSignal(t *testing.T) {
+	e, _, ts := newEmulator(t, Config{Password: "secret"})
+	client := newClient(t, ts.URL, "secret")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	signal, err := client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+	assert.Equal(t, float64(DefaultSignal.RSRP), *signal.RSRP)
+	assert.Equal(t, float64(DefaultSignal.RSRQ), *signal.RSRQ)
+	assert.Equal(t, float64(DefaultSignal.SINR), *signal.SINR)
+	assert.Equal(t, float64(DefaultSignal.PUSCH), *signal.Power.PUSCH)
+	assert.Equal(t, DefaultSignal.EARFCNDL, *signal.EARFCN.Downlink)
+	assert.Equal(t, float64(DefaultSignal.ULBandwidth), *signal.Bandwidth.Upload)
+	assert.Equal(t, routerclient.NetworkModeLTE, signal.Mode)
+	assert.Equal(t, DefaultSignal.PLMN, signal.PLMN)
+	assert.Equal(t, DefaultSignal.CellID, *signal.CellID)
+	assert.Empty(t, signal.Warnings)
+	assert.Equal(t, []routerclient.CarrierMCS{{Carrier: 1, MCS: DefaultSignal.ULMCS}}, signal.MCS.Uplink)
+
+	state, err := client.LoginState()
+	assert.Nil(t, err, "error getting login state: %q", err)
+	assert.True(t, state.LoggedIn())
+
+	err = client.Logout()
+	assert.Nil(t, err, "error logging out: %q", err)
+
+	assert.Equal(t, Stats{Logins: 1, Sessions: 1}, e.Stats())
+}
+
+func TestSignalIsNotAvailableBeforeLogin(t *testing.T) {
+	_, _, ts := newEmulator(t, Config{})
+	client := newClient(t, ts.URL, "admin")
+
+	_, err := client.GetSignalStats()
+
+	assert.True(t, errors.Is(err, routerclient.ErrSessionTimeout), "unexpected error: %v", err)
+}
+
+func TestWrongPasswordLocksLogin(t *testing.T) {
+	e, c, ts := newEmulator(t, Config{MaxLoginAttempts: 2, LockoutDuration: 2 * time.Minute})
+
+	err := newClient(t, ts.URL, "wrong").Login()
+	var credentialsErr *routerclient.CredentialsError
+	assert.True(t, errors.As(err, &credentialsErr), "unexpected error: %v", err)
+	assert.Equal(t, 1, credentialsErr.RemainingAttempts)
+
+	err = newClient(t, ts.URL, "wrong").Login()
+	var lockoutErr *routerclient.LockoutError
+	assert.True(t, errors.As(err, &lockoutErr), "unexpected error: %v", err)
+	assert.Equal(t, 2*time.Minute, lockoutErr.Wait)
+
+	client := newClient(t, ts.URL, "admin")
+	state, err := client.LoginState()
+	assert.Nil(t, err, "error getting login state: %q", err)
+	assert.True(t, state.Locked)
+	assert.Equal(t, 2*time.Minute, state.RemainingWait)
+
+	err = client.Login()
+	assert.True(t, errors.Is(err, routerclient.ErrTooManyAttempts), "correct password should be refused while locked: %v", err)
+
+	c.Advance(2 * time.Minute)
+
+	err = newClient(t, ts.URL, "admin").Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	assert.Equal(t, Stats{Logins: 1, FailedLogins: 2, Sessions: 4}, e.Stats())
+}
+
+func TestClientLogsInAgainAfterSessionExpired(t *testing.T) {
+	e, c, ts := newEmulator(t, Config{SessionTimeout: time.Minute})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	e.ExpireSessions()
+	_, err = client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+
+	c.Advance(2 * time.Minute)
+	_, err = client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+
+	assert.Equal(t, 3, e.Stats().Logins)
+}
+
+func TestRebootTakesRouterOffline(t *testing.T) {
+	e, c, ts := newEmulator(t, Config{RebootDuration: 90 * time.Second})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+	assert.False(t, e.Online())
+
+	err = client.Login()
+	assert.NotNil(t, err, "router should not be reachable while rebooting")
+
+	c.Advance(90 * time.Second)
+	assert.True(t, e.Online())
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	_, err = client.GetSignalStats()
+	assert.Nil(t, err, "error getting signal stats: %q", err)
+	assert.Equal(t, 1, e.Stats().Reboots)
+}
+
+func TestDeviceInformation(t *testing.T) {
+	_, c, ts := newEmulator(t, Config{RebootDuration: time.Minute})
+	client := newClient(t, ts.URL, "admin")
+
+	basic, err := client.GetBasicDeviceInfo()
+	assert.Nil(t, err, "error getting basic device info: %q", err)
+	assert.Equal(t, DefaultDevice.DeviceName, basic.DeviceName, "basic information should be available before login")
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	c.Advance(time.Hour)
+	info, err := client.GetDeviceInfo()
+	assert.Nil(t, err, "error getting device info: %q", err)
+	assert.Equal(t, DefaultDevice.IMEI, info.IMEI)
+	assert.Equal(t, DefaultDevice.SoftwareVersion, info.SoftwareVersion)
+	assert.Equal(t, 3600, *info.Uptime)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+	c.Advance(time.Minute + 5*time.Second)
+
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+	info, err = client.GetDeviceInfo()
+	assert.Nil(t, err, "error getting device info: %q", err)
+	assert.Equal(t, 5, *info.Uptime, "uptime should be counted from reboot")
+}
+
+func TestConnectionStatus(t *testing.T) {
+	e, _, ts := newEmulator(t, Config{})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	status, err := client.GetStatus()
+	assert.Nil(t, err, "error getting status: %q", err)
+	assert.Equal(t, routerclient.ConnectionConnected, status.Connection)
+	assert.Equal(t, "LTE", status.NetworkType)
+	assert.Equal(t, DefaultDevice.WANIPAddress, status.WANIPAddress)
+	assert.Equal(t, 4, *status.SignalIcon)
+	assert.Empty(t, status.Warnings)
+
+	e.SetConnected(false)
+	status, err = client.GetStatus()
+	assert.Nil(t, err, "error getting status: %q", err)
+	assert.Equal(t, routerclient.ConnectionDisconnected, status.Connection)
+	assert.Equal(t, "", status.WANIPAddress)
+}
+
+func TestTrafficStatistics(t *testing.T) {
+	_, c, ts := newEmulator(t, Config{RebootDuration: time.Minute})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	c.Advance(10 * time.Second)
+	stats, err := client.GetTrafficStatistics()
+	assert.Nil(t, err, "error getting traffic statistics: %q", err)
+	assert.Equal(t, 10, *stats.CurrentConnectTime)
+	assert.Equal(t, int64(10*downloadRate), *stats.CurrentDownload)
+	assert.Equal(t, int64(10*uploadRate), *stats.TotalUpload)
+
+	month, err := client.GetMonthStatistics()
+	assert.Nil(t, err, "error getting month statistics: %q", err)
+	assert.Equal(t, int64(10*(downloadRate+uploadRate)), *month.DayUsed)
+
+	err = client.Reboot()
+	assert.Nil(t, err, "error rebooting: %q", err)
+	c.Advance(time.Minute + 5*time.Second)
+	err = client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	stats, err = client.GetTrafficStatistics()
+	assert.Nil(t, err, "error getting traffic statistics: %q", err)
+	assert.Equal(t, 5, *stats.CurrentConnectTime, "current connection should start with reboot")
+	assert.Equal(t, 75, *stats.TotalConnectTime)
+
+	err = client.ClearTraffic()
+	assert.Nil(t, err, "error clearing traffic: %q", err)
+	month, err = client.GetMonthStatistics()
+	assert.Nil(t, err, "error getting month statistics: %q", err)
+	assert.Equal(t, int64(0), *month.Used())
+	assert.Equal(t, int64(0), *month.DayUsed)
+	assert.Equal(t, "2020-10-1", month.LastClearTime)
+}
+
+func TestDataPlan(t *testing.T) {
+	_, _, ts := newEmulator(t, Config{})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	plan, err := client.GetDataPlan()
+	assert.Nil(t, err, "error getting data plan: %q", err)
+	assert.Equal(t, routerclient.DataPlan{StartDay: 1, Threshold: 90}, plan)
+
+	plan = routerclient.DataPlan{Enabled: true, StartDay: 15, DataLimit: 20 << 30, Threshold: 80}
+	err = client.SetDataPlan(plan)
+	assert.Nil(t, err, "error setting data plan: %q", err)
+
+	stored, err := client.GetDataPlan()
+	assert.Nil(t, err, "error getting data plan: %q", err)
+	assert.Equal(t, plan, stored)
+}
+
+func TestTokensRepeatedAfterLoginAreUsedOnce(t *testing.T) {
+	e, _, ts := newEmulator(t, Config{})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	// token used twice would be rejected and the client would log in again
+	for i := 1; i <= 5; i++ {
+		err = client.SetDataPlan(routerclient.DataPlan{StartDay: i})
+		assert.Nil(t, err, "error setting data plan: %q", err)
+	}
+
+	assert.Equal(t, 1, e.Stats().Logins)
+}
+
+func TestEncryptedRequestRoundTrip(t *testing.T) {
+	_, _, ts := newEmulator(t, Config{})
+	client := newClient(t, ts.URL, "admin")
+
+	err := client.Login()
+	assert.Nil(t, err, "error logging in: %q", err)
+
+	body := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><request><StartDay>20</StartDay><DataLimit>2GB</DataLimit><MonthThreshold>75</MonthThreshold><SetMonthData>1</SetMonthData></request>"
+	response, err := client.PostEncrypted("/api/monitoring/start_date", []byte(body))
+	assert.Nil(t, err, "error sending encrypted request: %q", err)
+	assert.Contains(t, string(response), "<response>OK</response>")
+
+	plan, err := client.GetDataPlan()
+	assert.Nil(t, err, "error getting data plan: %q", err)
+	assert.Equal(t, routerclient.DataPlan{Enabled: true, StartDay: 20, DataLimit: 2 << 30, Threshold: 75}, plan)
+}
+
+// rawClient sends requests to emulator directly, keeping the session cookie
+type rawClient struct {
+	t      *testing.T
+	url    string
+	client *http.Client
+}
+
+func newRawClient(t *testing.T, url string) *rawClient {
+	jar, _ := cookiejar.New(nil)
+	return &rawClient{t: t, url: url, client: &http.Client{Jar: jar}}
+}
+
+func (c *rawClient) do(method string, path string, token string, body string) (http.Header, string) {
+	req, err := http.NewRequest(method, c.url+path, strings.NewReader(body))
+	assert.Nil(c.t, err, "error creating request: %q", err)
+	if token != "" {
+		req.Header.Set(requestVerificationToken, token)
+	}
+
+	resp, err := c.client.Do(req)
+	assert.Nil(c.t, err, "error sending request: %q", err)
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(c.t, err, "error reading response: %q", err)
+
+	return resp.Header, string(data)
+}
+
+func (c *rawClient) token() string {
+	_, body := c.do("GET", "/api/webserver/token", "", "")
+	start := strings.Index(body, "<token>") + len("<token>")
+	return body[start+32 : strings.Index(body, "</token>")]
+}
+
+func TestTokensCanBeUsedOnce(t *testing.T) {
+	_, _, ts := newEmulator(t, Config{})
+	client := newRawClient(t, ts.URL)
+	client.do("GET", "/", "", "")
+
+	token := client.token()
+	request := "<request><username>admin</username><firstnonce>abcd</firstnonce><mode>1</mode></request>"
+
+	header, body := client.do("POST", "/api/user/challenge_login", token, request)
+	assert.Contains(t, body, "<servernonce>abcd")
+	next := header.Get(requestVerificationToken)
+	assert.NotEmpty(t, next, "new token should be returned")
+
+	_, body = client.do("POST", "/api/user/challenge_login", token, request)
+	assert.Contains(t, body, "<code>125002</code>")
+
+	_, body = client.do("POST", "/api/user/challenge_login", next, request)
+	assert.Contains(t, body, "<servernonce>abcd")
+}
+
+func TestErrorCodes(t *testing.T) {
+	_, _, ts := newEmulator(t, Config{})
+	client := newRawClient(t, ts.URL)
+
+	_, body := client.do("GET", "/api/device/unknown", "", "")
+	assert.Contains(t, body, "<code>100002</code>")
+
+	_, body = client.do("POST", "/api/device/control", client.token(), "<request><Control>1</Control></request>")
+	assert.Contains(t, body, "<code>100003</code>")
+
+	_, body = client.do("POST", "/api/user/challenge_login", client.token(), "<request><username>root</username><firstnonce>abcd</firstnonce><mode>1</mode></request>")
+	assert.Contains(t, body, "<code>108001</code>")
+
+	_, body = client.do("POST", "/api/user/authentication_login", client.token(), "<request><clientproof>00</clientproof><finalnonce>abcd</finalnonce></request>")
+	assert.Contains(t, body, "<code>100005</code>")
+}
+
+func TestSignalWalksRandomly(t *testing.T) {
+	e, err := New(Config{RSAKeyBits: 1024, RandomWalk: true, Seed: 1})
+	assert.Nil(t, err, "error creating emulator: %q", err)
+
+	changed := false
+	previous := e.Signal()
+	for i := 0; i < 1000; i++ {
+		e.walk()
+		current := e.Signal()
+
+		assert.LessOrEqual(t, abs(current.RSRP-previous.RSRP), 1)
+		assert.LessOrEqual(t, abs(current.SINR-previous.SINR), 1)
+		assert.GreaterOrEqual(t, current.RSRP, -140)
+		assert.LessOrEqual(t, current.RSRQ, -3)
+
+		changed = changed || current != previous
+		previous = current
+	}
+	assert.True(t, changed, "signal should change")
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestEncryptedRequestIsDecrypted(t *testing.T) {
+	e, err := New(Config{RSAKeyBits: 1024})
+	assert.Nil(t, err, "error creating emulator: %q", err)
+
+	body := strings.Repeat("<request><Control>1</Control></request>", 5)
+	plain := []byte(base64.StdEncoding.EncodeToString([]byte(body)))
+	chunkSize := e.key.Size() - 2*sha1.Size - 2
+
+	var encrypted string
+	for start := 0; start < len(plain); start += chunkSize {
+		end := start + chunkSize
+		if end > len(plain) {
+			end = len(plain)
+		}
+		chunk, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, &e.key.PublicKey, plain[start:end], nil)
+		assert.Nil(t, err, "error encrypting: %q", err)
+		encrypted += hex.EncodeToString(chunk)
+	}
+
+	decrypted, err := e.decrypt([]byte(encrypted))
+	assert.Nil(t, err, "error decrypting: %q", err)
+	assert.Equal(t, body, string(decrypted))
+}
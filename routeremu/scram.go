@@ -0,0 +1,98 @@
+package routeremu
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// scramKeys derives the keys router stores instead of the password
+func scramKeys(password string, salt string, iterations int) (storedKey []byte, serverKey []byte, err error) {
+	saltArray, err := hex.DecodeString(salt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	saltedPass := pbkdf2.Key([]byte(password), saltArray, iterations, 32, sha256.New)
+	clientKey := hmacSHA256([]byte("Client Key"), saltedPass)
+	stored := sha256.Sum256(clientKey)
+
+	return stored[:], hmacSHA256([]byte("Server Key"), saltedPass), nil
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func authMessage(clientNonce string, serverNonce string) []byte {
+	return []byte(fmt.Sprintf("%s,%s,%s", clientNonce, serverNonce, serverNonce))
+}
+
+// verifyClientProof recovers the client key from the proof and checks it against the stored key
+func (e *Emulator) verifyClientProof(clientProof string, clientNonce string, serverNonce string) bool {
+	proof, err := hex.DecodeString(clientProof)
+	if err != nil || len(proof) != sha256.Size {
+		return false
+	}
+
+	clientSignature := hmacSHA256(authMessage(clientNonce, serverNonce), e.storedKey)
+	clientKey := make([]byte, len(proof))
+	for i := range proof {
+		clientKey[i] = proof[i] ^ clientSignature[i]
+	}
+
+	storedKey := sha256.Sum256(clientKey)
+	return hmac.Equal(storedKey[:], e.storedKey)
+}
+
+// serverSignature proves to the client that router knows the password. Like publicKeySignature,
+// it follows the web interface scripts, but was not checked against a physical router, so
+// routerclient does not verify it.
+func (e *Emulator) serverSignature(clientNonce string, serverNonce string) string {
+	return hex.EncodeToString(hmacSHA256(authMessage(clientNonce, serverNonce), e.serverKey))
+}
+
+// publicKeySignature proves to the client that the public key comes from router
+func (e *Emulator) publicKeySignature() string {
+	return hex.EncodeToString(hmacSHA256(e.serverKey, e.key.N.Bytes()))
+}
+
+// decrypt reverses the encryption of request body done by the client: hex encoded
+// RSA-OAEP encrypted chunks of base64 encoded body
+func (e *Emulator) decrypt(body []byte) ([]byte, error) {
+	encrypted, err := hex.DecodeString(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	size := e.key.Size()
+	if len(encrypted) == 0 || len(encrypted)%size != 0 {
+		return nil, errors.New("invalid encrypted body length")
+	}
+
+	var plain []byte
+	for start := 0; start < len(encrypted); start += size {
+		chunk, err := rsa.DecryptOAEP(sha1.New(), nil, e.key, encrypted[start:start+size], nil)
+		if err != nil {
+			return nil, err
+		}
+		plain = append(plain, chunk...)
+	}
+
+	return base64.StdEncoding.DecodeString(string(plain))
+}
+
+// publicKey returns modulus and exponent of the router public key as hex strings
+func (e *Emulator) publicKey() (string, string) {
+	return hex.EncodeToString(e.key.N.Bytes()), hex.EncodeToString(big.NewInt(int64(e.key.E)).Bytes())
+}
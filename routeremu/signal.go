@@ -0,0 +1,120 @@
+package routeremu
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// Signal is the LTE signal reported by the emulated router
+type Signal struct {
+	PLMN   string
+	TAC    int
+	PCI    int
+	CellID int
+	Band   int
+	// RSRQ, SINR in dB, RSRP, RSSI in dBm
+	RSRQ int
+	RSRP int
+	RSSI int
+	SINR int
+	// ULBandwidth, DLBandwidth in MHz
+	ULBandwidth int
+	DLBandwidth int
+	// transmit power in dBm
+	PUSCH int
+	PUCCH int
+	SRS   int
+	PRACH int
+	// EARFCN of downlink and uplink
+	EARFCNDL int
+	EARFCNUL int
+	// MCS of the primary carrier
+	ULMCS int
+	DLMCS int
+}
+
+// DefaultSignal is a typical signal of B618 connected to band 7 cell
+var DefaultSignal = Signal{
+	PLMN:        "26003",
+	TAC:         57332,
+	PCI:         43,
+	CellID:      44294436,
+	Band:        7,
+	RSRQ:        -14,
+	RSRP:        -86,
+	RSSI:        -61,
+	SINR:        10,
+	ULBandwidth: 15,
+	DLBandwidth: 15,
+	PUSCH:       8,
+	PUCCH:       -5,
+	SRS:         0,
+	PRACH:       -4,
+	EARFCNDL:    3025,
+	EARFCNUL:    21025,
+	ULMCS:       22,
+	DLMCS:       25,
+}
+
+// walk changes signal values by at most 1, keeping them in realistic ranges
+func (e *Emulator) walk() {
+	step := func(v *int, min int, max int) {
+		*v += e.rand.Intn(3) - 1
+		if *v < min {
+			*v = min
+		}
+		if *v > max {
+			*v = max
+		}
+	}
+
+	step(&e.signal.RSRQ, -20, -3)
+	step(&e.signal.RSRP, -140, -44)
+	step(&e.signal.RSSI, -113, -51)
+	step(&e.signal.SINR, -20, 30)
+}
+
+func (e *Emulator) handleSignal(s *session, w http.ResponseWriter, body []byte) {
+	if e.cfg.RandomWalk {
+		e.walk()
+	}
+
+	sig := e.signal
+	writeResponse(w, struct {
+		XMLName     xml.Name `xml:"response"`
+		PCI         int      `xml:"pci"`
+		CellID      int      `xml:"cell_id"`
+		RSRQ        string   `xml:"rsrq"`
+		RSRP        string   `xml:"rsrp"`
+		RSSI        string   `xml:"rssi"`
+		SINR        string   `xml:"sinr"`
+		Mode        int      `xml:"mode"`
+		ULBandwidth string   `xml:"ulbandwidth"`
+		DLBandwidth string   `xml:"dlbandwidth"`
+		TxPower     string   `xml:"txpower"`
+		EARFCN      string   `xml:"earfcn"`
+		ULMCS       string   `xml:"ul_mcs"`
+		DLMCS       string   `xml:"dl_mcs"`
+		TAC         int      `xml:"tac"`
+		Band        int      `xml:"band"`
+		PLMN        string   `xml:"plmn"`
+	}{
+		PCI:         sig.PCI,
+		CellID:      sig.CellID,
+		RSRQ:        fmt.Sprintf("%ddB", sig.RSRQ),
+		RSRP:        fmt.Sprintf("%ddBm", sig.RSRP),
+		RSSI:        fmt.Sprintf("%ddBm", sig.RSSI),
+		SINR:        fmt.Sprintf("%ddB", sig.SINR),
+		Mode:        7,
+		ULBandwidth: fmt.Sprintf("%dMHz", sig.ULBandwidth),
+		DLBandwidth: fmt.Sprintf("%dMHz", sig.DLBandwidth),
+		TxPower:     fmt.Sprintf("PPusch:%ddBm PPucch:%ddBm PSrs:%ddBm PPrach:%ddBm", sig.PUSCH, sig.PUCCH, sig.SRS, sig.PRACH),
+		EARFCN:      fmt.Sprintf("DL:%d UL:%d", sig.EARFCNDL, sig.EARFCNUL),
+		ULMCS:       fmt.Sprintf("mcsUpCarrier1:%d", sig.ULMCS),
+		DLMCS:       fmt.Sprintf("mcsDownCarrier1Code0:%d mcsDownCarrier1Code1:%d", sig.DLMCS, sig.DLMCS),
+		TAC:         sig.TAC,
+		Band:        sig.Band,
+		PLMN:        sig.PLMN,
+	})
+}
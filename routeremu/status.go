@@ -0,0 +1,72 @@
+package routeremu
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// connection status codes reported by router
+const (
+	statusConnected    = 901
+	statusDisconnected = 902
+)
+
+// SetConnected connects or disconnects mobile data, as if the network was lost
+func (e *Emulator) SetConnected(connected bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.disconnected = !connected
+}
+
+// signalIcon returns the number of bars shown by router for RSRP
+func signalIcon(rsrp int) int {
+	icon := 0
+	for _, threshold := range []int{-120, -110, -100, -90, -80} {
+		if rsrp >= threshold {
+			icon++
+		}
+	}
+	return icon
+}
+
+func (e *Emulator) handleStatus(s *session, w http.ResponseWriter, body []byte) {
+	v := struct {
+		XMLName              xml.Name `xml:"response"`
+		ConnectionStatus     int      `xml:"ConnectionStatus"`
+		SignalIcon           int      `xml:"SignalIcon"`
+		CurrentNetworkType   int      `xml:"CurrentNetworkType"`
+		CurrentServiceDomain int      `xml:"CurrentServiceDomain"`
+		RoamingStatus        int      `xml:"RoamingStatus"`
+		WanIPAddress         string   `xml:"WanIPAddress"`
+		WanIPv6Address       string   `xml:"WanIPv6Address"`
+		PrimaryDNS           string   `xml:"PrimaryDns"`
+		SecondaryDNS         string   `xml:"SecondaryDns"`
+		PrimaryIPv6DNS       string   `xml:"PrimaryIPv6Dns"`
+		SecondaryIPv6DNS     string   `xml:"SecondaryIPv6Dns"`
+		CurrentWifiUser      int      `xml:"CurrentWifiUser"`
+		TotalWifiUser        int      `xml:"TotalWifiUser"`
+		SimStatus            int      `xml:"SimStatus"`
+		CurrentNetworkTypeEx int      `xml:"CurrentNetworkTypeEx"`
+		MaxSignal            int      `xml:"maxsignal"`
+	}{
+		ConnectionStatus:     statusConnected,
+		SignalIcon:           signalIcon(e.signal.RSRP),
+		CurrentNetworkType:   19,
+		CurrentServiceDomain: 3,
+		WanIPAddress:         e.cfg.Device.WANIPAddress,
+		PrimaryDNS:           "10.1.1.1",
+		SecondaryDNS:         "10.1.1.2",
+		CurrentWifiUser:      2,
+		TotalWifiUser:        32,
+		SimStatus:            1,
+		CurrentNetworkTypeEx: 101,
+		MaxSignal:            5,
+	}
+
+	if e.disconnected {
+		v.ConnectionStatus = statusDisconnected
+		v.WanIPAddress, v.PrimaryDNS, v.SecondaryDNS = "", "", ""
+	}
+
+	writeResponse(w, v)
+}
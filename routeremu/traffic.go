@@ -0,0 +1,139 @@
+package routeremu
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// data rates of the emulated connection, in bytes per second
+const (
+	downloadRate = 1250000
+	uploadRate   = 125000
+)
+
+// dataPlan is the monthly data plan set in router
+type dataPlan struct {
+	StartDay       int    `xml:"StartDay"`
+	DataLimit      string `xml:"DataLimit"`
+	MonthThreshold int    `xml:"MonthThreshold"`
+	SetMonthData   int    `xml:"SetMonthData"`
+}
+
+var defaultDataPlan = dataPlan{StartDay: 1, DataLimit: "0MB", MonthThreshold: 90}
+
+var dataLimitRegexp = regexp.MustCompile(`^\d+(MB|GB)$`)
+
+// seconds returns whole seconds since t, zero if t is in the future
+func (e *Emulator) seconds(t time.Time) int64 {
+	d := e.now().Sub(t)
+	if d < 0 {
+		return 0
+	}
+	return int64(d / time.Second)
+}
+
+// trafficSince returns the time counters are counted from, the later of the start and the last clear
+func (e *Emulator) trafficSince() time.Time {
+	if e.clearedAt.After(e.startedAt) {
+		return e.clearedAt
+	}
+	return e.startedAt
+}
+
+func (e *Emulator) handleTrafficStatistics(s *session, w http.ResponseWriter, body []byte) {
+	// the current connection begins with boot, router keeps transferring data at constant rates
+	current := e.bootedAt
+	if e.clearedAt.After(current) {
+		current = e.clearedAt
+	}
+	currentTime, totalTime := e.seconds(current), e.seconds(e.trafficSince())
+
+	writeResponse(w, struct {
+		XMLName             xml.Name `xml:"response"`
+		CurrentConnectTime  int64    `xml:"CurrentConnectTime"`
+		CurrentUpload       int64    `xml:"CurrentUpload"`
+		CurrentDownload     int64    `xml:"CurrentDownload"`
+		CurrentDownloadRate int64    `xml:"CurrentDownloadRate"`
+		CurrentUploadRate   int64    `xml:"CurrentUploadRate"`
+		TotalUpload         int64    `xml:"TotalUpload"`
+		TotalDownload       int64    `xml:"TotalDownload"`
+		TotalConnectTime    int64    `xml:"TotalConnectTime"`
+		ShowTraffic         int      `xml:"showtraffic"`
+	}{
+		CurrentConnectTime:  currentTime,
+		CurrentUpload:       currentTime * uploadRate,
+		CurrentDownload:     currentTime * downloadRate,
+		CurrentDownloadRate: downloadRate,
+		CurrentUploadRate:   uploadRate,
+		TotalUpload:         totalTime * uploadRate,
+		TotalDownload:       totalTime * downloadRate,
+		TotalConnectTime:    totalTime,
+		ShowTraffic:         1,
+	})
+}
+
+// handleMonthStatistics reports all traffic since the counters were cleared, billing months are not emulated
+func (e *Emulator) handleMonthStatistics(s *session, w http.ResponseWriter, body []byte) {
+	since := e.trafficSince()
+	monthTime := e.seconds(since)
+
+	now := e.now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if since.After(day) {
+		day = since
+	}
+	dayTime := e.seconds(day)
+
+	writeResponse(w, struct {
+		XMLName              xml.Name `xml:"response"`
+		CurrentMonthDownload int64    `xml:"CurrentMonthDownload"`
+		CurrentMonthUpload   int64    `xml:"CurrentMonthUpload"`
+		MonthDuration        int64    `xml:"MonthDuration"`
+		MonthLastClearTime   string   `xml:"MonthLastClearTime"`
+		CurrentDayUsed       int64    `xml:"CurrentDayUsed"`
+		CurrentDayDuration   int64    `xml:"CurrentDayDuration"`
+	}{
+		CurrentMonthDownload: monthTime * downloadRate,
+		CurrentMonthUpload:   monthTime * uploadRate,
+		MonthDuration:        monthTime,
+		MonthLastClearTime:   fmt.Sprintf("%d-%d-%d", since.Year(), since.Month(), since.Day()),
+		CurrentDayUsed:       dayTime * (downloadRate + uploadRate),
+		CurrentDayDuration:   dayTime,
+	})
+}
+
+func (e *Emulator) handleGetStartDate(s *session, w http.ResponseWriter, body []byte) {
+	writeResponse(w, struct {
+		XMLName xml.Name `xml:"response"`
+		dataPlan
+	}{dataPlan: e.plan})
+}
+
+func (e *Emulator) handleSetStartDate(s *session, w http.ResponseWriter, body []byte) {
+	plan := dataPlan{}
+	err := xml.Unmarshal(body, &plan)
+	if err != nil || plan.StartDay < 1 || plan.StartDay > 31 || !dataLimitRegexp.MatchString(plan.DataLimit) ||
+		plan.MonthThreshold < 0 || plan.MonthThreshold > 100 {
+		writeError(w, ErrorParameter, nil)
+		return
+	}
+
+	e.plan = plan
+	writeOK(w)
+}
+
+func (e *Emulator) handleClearTraffic(s *session, w http.ResponseWriter, body []byte) {
+	v := struct {
+		ClearTraffic int `xml:"ClearTraffic"`
+	}{}
+	if err := xml.Unmarshal(body, &v); err != nil || v.ClearTraffic != 1 {
+		writeError(w, ErrorParameter, nil)
+		return
+	}
+
+	e.clearedAt = e.now()
+	writeOK(w)
+}
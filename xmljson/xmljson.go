@@ -0,0 +1,153 @@
+// Package xmljson converts XML responses of router API to JSON.
+//
+// Elements with text only become strings, elements with child elements become objects,
+// keeping the order of the document. Repeated child elements become arrays. The root
+// element itself is omitted, so <response><a>1</a></response> becomes {"a":"1"}.
+package xmljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+type node struct {
+	name     string
+	text     strings.Builder
+	children []*node
+}
+
+// Convert returns JSON representation of XML document
+func Convert(data []byte) ([]byte, error) {
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = root.write(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func parse(data []byte) (*node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	// router responses declare UTF-8, any other charset is passed through as is
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			} else {
+				return nil, errors.New("xml document has more than one root element")
+			}
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, errors.New("unexpected end element " + t.Name.Local)
+			}
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("xml document is empty")
+	}
+
+	return root, nil
+}
+
+// write writes the node value, text for leaf elements and object otherwise
+func (n *node) write(buf *bytes.Buffer) error {
+	if len(n.children) == 0 {
+		return writeString(buf, strings.TrimSpace(n.text.String()))
+	}
+
+	// group repeated elements at the position of the first one
+	var names []string
+	groups := map[string][]*node{}
+	for _, c := range n.children {
+		if _, ok := groups[c.name]; !ok {
+			names = append(names, c.name)
+		}
+		groups[c.name] = append(groups[c.name], c)
+	}
+
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := writeString(buf, name); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+
+		group := groups[name]
+		if len(group) == 1 {
+			if err := group[0].write(buf); err != nil {
+				return err
+			}
+			continue
+		}
+
+		buf.WriteByte('[')
+		for j, c := range group {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			if err := c.write(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// writeString writes JSON string, without escaping HTML characters common in router responses
+func writeString(buf *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+
+	// drop new line added by encoder
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
@@ -0,0 +1,43 @@
+package xmljson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertKeepsDocumentOrder(t *testing.T) {
+	out, err := Convert([]byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>\n<ConnectionStatus>901</ConnectionStatus>\n<SignalIcon>4</SignalIcon>\n<CurrentWifiUser></CurrentWifiUser>\n</response>\n"))
+
+	assert.Nil(t, err, "error converting: %q", err)
+	assert.Equal(t, `{"ConnectionStatus":"901","SignalIcon":"4","CurrentWifiUser":""}`, string(out))
+}
+
+func TestConvertRepeatedElementsToArray(t *testing.T) {
+	out, err := Convert([]byte(`<response><Count>2</Count><Messages><Message><Index>1</Index><Content>a &amp; "b"</Content></Message><Message><Index>2</Index><Content>c</Content></Message></Messages></response>`))
+
+	assert.Nil(t, err, "error converting: %q", err)
+	assert.Equal(t, `{"Count":"2","Messages":{"Message":[{"Index":"1","Content":"a & \"b\""},{"Index":"2","Content":"c"}]}}`, string(out))
+	assert.True(t, json.Valid(out))
+}
+
+func TestConvertTextRoot(t *testing.T) {
+	out, err := Convert([]byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response>OK</response>\n"))
+
+	assert.Nil(t, err, "error converting: %q", err)
+	assert.Equal(t, `"OK"`, string(out))
+}
+
+func TestConvertRejectsInvalidXML(t *testing.T) {
+	tests := map[string]string{
+		"":                                 "xml document is empty",
+		"<response><a>1</a>":               "XML syntax error on line 1: unexpected EOF",
+		"<response></response><response/>": "xml document has more than one root element",
+	}
+
+	for input, expected := range tests {
+		_, err := Convert([]byte(input))
+		assert.EqualError(t, err, expected, "input %q", input)
+	}
+}